@@ -2,72 +2,135 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/actions"
 	"github.com/dandoyle-pdm/workflow-guard/engine/internal/conditions"
 	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/daemon"
 	"github.com/dandoyle-pdm/workflow-guard/engine/internal/rules"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/session"
 )
 
 func main() {
-	// Fail-safe: On any error, exit 0 (continue normally)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve":
+			serve()
+			return
+		default:
+			fmt.Fprintln(os.Stderr, "Usage: dispatcher [serve]")
+			os.Exit(1)
+		}
+	}
+
+	// Failures follow the on_error policy: WORKFLOW_GUARD_ON_ERROR until
+	// the config is loaded, then its on_error setting if it has one
+	policy := rules.DefaultOnError()
+	hookType := os.Getenv("CLAUDE_HOOK_TYPE")
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Fprintf(os.Stderr, "Hook engine panic: %v\n", r)
-			os.Exit(0)
+			fail(policy, hookType, fmt.Errorf("hook engine panic: %v", r))
 		}
 	}()
 
+	// Read event from stdin
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		fail(policy, hookType, fmt.Errorf("failed to read event: %w", err))
+	}
+	if hookType == "" {
+		hookType = eventName(data)
+	}
+
+	env := conditions.Environ()
+
+	// Prefer the daemon when one is running
+	if os.Getenv("WORKFLOW_GUARD_NO_DAEMON") == "" {
+		req := &daemon.Request{Event: data, Env: env, ProjectDir: env["CLAUDE_PROJECT_DIR"]}
+		response, err := daemon.Forward(daemon.SocketPath(), req, daemon.DefaultTimeout)
+		switch {
+		case err == nil:
+			respond(response)
+		case !errors.Is(err, daemon.ErrUnavailable):
+			// The daemon had the event and may have run its actions;
+			// evaluating it again here would run them twice
+			if cfg, loadErr := config.LoadConfig(); loadErr == nil {
+				policy = rules.GlobalOnError(cfg, env)
+			}
+			fail(policy, hookType, fmt.Errorf("dispatcher daemon: %w", err))
+		}
+	}
+
 	// Load configuration
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
-		os.Exit(0) // Fail-safe
+		fail(policy, hookType, fmt.Errorf("failed to load config: %w", err))
 	}
+	policy = rules.GlobalOnError(cfg, env)
 
-	// Parse event from stdin
-	var event conditions.HookEvent
-	if err := json.NewDecoder(os.Stdin).Decode(&event); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to parse event: %v\n", err)
-		os.Exit(0) // Fail-safe
+	// Dispatch to rule engine
+	response, err := rules.Run(data, env, cfg, session.NewStore(session.DefaultDir(), false))
+	if err != nil {
+		fail(policy, hookType, err)
 	}
 
-	// Store raw data for field access
-	event.Raw = make(map[string]any)
-	event.Raw["hook_type"] = event.HookType
-	event.Raw["tool_name"] = event.ToolName
-	event.Raw["session_id"] = event.SessionID
-	event.Raw["tool_input"] = event.ToolInput
-
-	// Add environment variables for condition evaluation
-	envMap := make(map[string]any)
-	if skipConfirm := os.Getenv("SKIP_EDIT_CONFIRMATION"); skipConfirm != "" {
-		envMap["SKIP_EDIT_CONFIRMATION"] = skipConfirm
-	}
-	event.Raw["env"] = envMap
+	respond(response)
+}
 
-	// Also check environment for hook type
-	if envHookType := os.Getenv("CLAUDE_HOOK_TYPE"); envHookType != "" {
-		event.HookType = envHookType
-		event.Raw["hook_type"] = envHookType
+// fail answers an event the engine could not process according to the
+// on_error policy and exits
+func fail(policy, hookType string, err error) {
+	fmt.Fprintf(os.Stderr, "%v (on_error: %s)\n", err, policy)
+	respond(rules.ErrorResponse(policy, hookType, err))
+}
+
+// eventName reads hook_event_name from an event, even one whose other
+// fields don't match the expected types
+func eventName(data []byte) string {
+	var event struct {
+		Name string `json:"hook_event_name"`
 	}
+	json.Unmarshal(data, &event)
+	return event.Name
+}
 
-	// Dispatch to rule engine
-	response := rules.Dispatch(&event, cfg)
-
-	// Output response in official Claude Code format
-	if response.Decision != "" {
-		hookOutput := map[string]interface{}{
-			"hookEventName":             "PreToolUse",
-			"permissionDecision":        response.Decision,
-			"permissionDecisionReason":  response.Message,
-		}
-		output := map[string]interface{}{
-			"hookSpecificOutput": hookOutput,
-		}
+// respond writes the response in official Claude Code format for its
+// event type and exits. Exit code 2 blocks with the message on stderr.
+func respond(response *actions.Response) {
+	if response.ExitCode == 2 {
+		fmt.Fprintln(os.Stderr, response.Message)
+		os.Exit(2)
+	}
+	if output := response.HookOutput(); output != nil {
 		json.NewEncoder(os.Stdout).Encode(output)
 	}
 
 	os.Exit(response.ExitCode)
 }
+
+// serve runs the long-lived dispatcher daemon
+func serve() {
+	server := daemon.NewServer(daemon.SocketPath())
+	server.Logf = func(format string, args ...any) {
+		fmt.Fprintf(os.Stderr, "dispatcher: "+format+"\n", args...)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signals
+		server.Close()
+	}()
+
+	fmt.Fprintf(os.Stderr, "dispatcher: listening on %s\n", server.SocketPath)
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Fprintf(os.Stderr, "dispatcher: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -3,12 +3,27 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/actions"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/audit"
 	"github.com/dandoyle-pdm/workflow-guard/engine/internal/conditions"
 	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/cycle"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/gitstate"
 	"github.com/dandoyle-pdm/workflow-guard/engine/internal/rules"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/secrets"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/session"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/ticket"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/webhook"
 )
 
 func main() {
@@ -21,16 +36,12 @@ func main() {
 
 	switch command {
 	case "list":
-		cmdList()
+		cmdList(os.Args[2:])
 	case "test":
-		if len(os.Args) < 3 {
-			fmt.Println("Usage: hookctl test <event.json>")
-			os.Exit(1)
-		}
-		cmdTest(os.Args[2])
+		cmdTest(os.Args[2:])
 	case "config":
 		if len(os.Args) < 3 {
-			fmt.Println("Usage: hookctl config <show|validate>")
+			fmt.Println("Usage: hookctl config <show|validate|lock|verify>")
 			os.Exit(1)
 		}
 		switch os.Args[2] {
@@ -38,10 +49,32 @@ func main() {
 			cmdConfigShow()
 		case "validate":
 			cmdConfigValidate()
+		case "lock":
+			cmdConfigLock(os.Args[3:])
+		case "verify":
+			cmdConfigVerify()
 		default:
 			fmt.Println("Unknown config command:", os.Args[2])
 			os.Exit(1)
 		}
+	case "spool":
+		if len(os.Args) < 3 || os.Args[2] != "flush" {
+			fmt.Println("Usage: hookctl spool flush [--timeout MS]")
+			os.Exit(1)
+		}
+		cmdSpoolFlush(os.Args[3:])
+	case "ticket":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: hookctl ticket <activate|complete|cleanup|lint> ...")
+			os.Exit(1)
+		}
+		if os.Args[2] == "lint" {
+			cmdTicketLint(os.Args[3:])
+			return
+		}
+		cmdTicket(os.Args[2], os.Args[3:])
+	case "statusline":
+		cmdStatusline(os.Args[2:])
 	default:
 		fmt.Println("Unknown command:", command)
 		printUsage()
@@ -53,27 +86,85 @@ func printUsage() {
 	fmt.Println("hookctl - Claude Hooks Engine CLI")
 	fmt.Println()
 	fmt.Println("Usage:")
-	fmt.Println("  hookctl list               List all loaded rules")
-	fmt.Println("  hookctl test <event.json>  Test rule matching against event file")
+	fmt.Println("  hookctl list [--profile NAME] [--session ID]")
+	fmt.Println("                             List rules active under a profile")
+	fmt.Println("  hookctl test [--dry-run] <event.json>")
+	fmt.Println("                             Test rule matching against event file")
 	fmt.Println("  hookctl config show        Show configuration sources")
 	fmt.Println("  hookctl config validate    Validate configuration")
+	fmt.Println("  hookctl config lock [--on-drift refuse|deny|ask]")
+	fmt.Println("                             Pin config and script hashes in a manifest")
+	fmt.Println("  hookctl config verify      Report drift from the manifest")
+	fmt.Println("  hookctl spool flush [--timeout MS]")
+	fmt.Println("                             Retry webhook deliveries that failed")
+	fmt.Println("  hookctl ticket activate <queue-ticket> [project]")
+	fmt.Println("                             Claim a queued ticket and create its worktree")
+	fmt.Println("  hookctl ticket complete [ticket]")
+	fmt.Println("                             Approve the worktree's ticket and move it to completed/")
+	fmt.Println("  hookctl ticket cleanup <branch>")
+	fmt.Println("                             Remove a merged ticket's worktree and branches")
+	fmt.Println("                             (these take --dry-run, --no-push, --base BRANCH)")
+	fmt.Println("  hookctl ticket lint [--json] [dir]")
+	fmt.Println("                             Audit the tickets tree")
+	fmt.Println("  hookctl statusline [--session ID] [dir]")
+	fmt.Println("                             Print project:branch, agent and ticket for the status line")
 }
 
-func cmdList() {
+func cmdList(args []string) {
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
 		os.Exit(1)
 	}
 
+	// Resolve the profile the same way the dispatcher would
+	probe := &conditions.HookEvent{Env: conditions.Environ()}
+	profileOverride := ""
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--profile" && i+1 < len(args):
+			i++
+			profileOverride = args[i]
+		case args[i] == "--session" && i+1 < len(args):
+			i++
+			state, release := session.NewStore(session.DefaultDir(), false).Acquire(args[i])
+			release()
+			probe.Session = state
+		default:
+			fmt.Println("Usage: hookctl list [--profile NAME] [--session ID]")
+			os.Exit(1)
+		}
+	}
+	profile, source := rules.ActiveProfile(probe, cfg)
+	if profileOverride != "" {
+		profile, source = profileOverride, "flag"
+	}
+
 	fmt.Println()
 	fmt.Println(strings.Repeat("=", 60))
 	fmt.Printf(" Active Rules (%d total)\n", len(cfg.Rules))
 	fmt.Println(strings.Repeat("=", 60))
 	fmt.Println()
 
+	switch {
+	case profile == "":
+		fmt.Println("Profile: (none)")
+	case !hasProfile(cfg, profile):
+		fmt.Printf("Profile: %s (from %s) ⚠ not defined, rules use their own settings\n", profile, source)
+	default:
+		fmt.Printf("Profile: %s (from %s)\n", profile, source)
+	}
+	fmt.Println()
+
+	for _, rule := range rules.BuiltinRules {
+		fmt.Printf("[%s] %s\n", "BUILTIN", rule.Name)
+		fmt.Printf("  ID: %s (always on)\n", rule.ID)
+		fmt.Printf("  Trigger: %s → %s\n", rule.Trigger.Event, rule.Trigger.Matcher)
+		fmt.Println()
+	}
+
 	for _, rule := range cfg.Rules {
-		if !rule.Enabled {
+		if !rules.RuleActive(&rule, profile, cfg) {
 			continue
 		}
 
@@ -85,15 +176,24 @@ func cmdList() {
 		if len(rule.Tags) > 0 {
 			fmt.Printf("  Tags: %s\n", strings.Join(rule.Tags, ", "))
 		}
+		if !rule.Enabled {
+			fmt.Printf("  Enabled by profile: %s\n", profile)
+		}
 		fmt.Printf("  Actions: %d\n", len(rule.Actions))
 		fmt.Println()
 	}
 
+	for _, rule := range cfg.Rules {
+		if rule.Enabled && !rules.RuleActive(&rule, profile, cfg) {
+			fmt.Printf("  (disabled by profile %s: %s)\n", profile, rule.ID)
+		}
+	}
+
 	// Summary
 	events := make(map[string]bool)
 	tags := make(map[string]bool)
 	for _, rule := range cfg.Rules {
-		if rule.Enabled {
+		if rules.RuleActive(&rule, profile, cfg) {
 			events[rule.Trigger.Event] = true
 			for _, tag := range rule.Tags {
 				tags[tag] = true
@@ -114,9 +214,41 @@ func cmdList() {
 	fmt.Printf("Tags used: %s\n", strings.Join(tagList, ", "))
 	fmt.Printf("Conditions defined: %d\n", len(cfg.Conditions))
 	fmt.Printf("Actions defined: %d\n", len(cfg.Actions))
+
+	if len(cfg.Settings.Profiles) > 0 {
+		names := []string{}
+		for name := range cfg.Settings.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Printf("Profiles available: %s\n", strings.Join(names, ", "))
+	}
+}
+
+func hasProfile(cfg *config.Config, name string) bool {
+	_, ok := cfg.Settings.Profiles[name]
+	return ok
 }
 
-func cmdTest(eventFile string) {
+func cmdTest(args []string) {
+	var eventFile string
+	dryRun := false
+	for _, arg := range args {
+		if arg == "--dry-run" {
+			dryRun = true
+			continue
+		}
+		if eventFile != "" || strings.HasPrefix(arg, "-") {
+			eventFile = ""
+			break
+		}
+		eventFile = arg
+	}
+	if eventFile == "" {
+		fmt.Println("Usage: hookctl test [--dry-run] <event.json>")
+		os.Exit(1)
+	}
+
 	// Load config
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -131,18 +263,17 @@ func cmdTest(eventFile string) {
 		os.Exit(1)
 	}
 
-	var event conditions.HookEvent
-	if err := json.Unmarshal(data, &event); err != nil {
+	event, err := conditions.NewEvent(data, nil, cfg)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to parse event JSON: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Prepare raw data
-	event.Raw = make(map[string]interface{})
-	event.Raw["hook_type"] = event.HookType
-	event.Raw["tool_name"] = event.ToolName
-	event.Raw["session_id"] = event.SessionID
-	event.Raw["tool_input"] = event.ToolInput
+	// Evaluate against the session's current state without saving changes
+	state, release := session.NewStore(session.DefaultDir(), false).Acquire(event.SessionID)
+	release()
+	event.AttachSession(state)
+	event.DryRun = dryRun
 
 	fmt.Println()
 	fmt.Println(strings.Repeat("=", 60))
@@ -157,7 +288,7 @@ func cmdTest(eventFile string) {
 	fmt.Printf("  Input: %s\n\n", string(inputJSON))
 
 	// Dispatch
-	response := rules.Dispatch(&event, cfg)
+	response := rules.Dispatch(event, cfg)
 
 	fmt.Println("Result:")
 	fmt.Printf("  Exit Code: %d\n", response.ExitCode)
@@ -167,19 +298,38 @@ func cmdTest(eventFile string) {
 	if response.Message != "" {
 		fmt.Printf("  Message: %s\n", response.Message)
 	}
+	for _, context := range response.AdditionalContext {
+		fmt.Printf("  Context: %s\n", context)
+	}
+	for _, message := range response.SystemMessages {
+		fmt.Printf("  System Message: %s\n", message)
+	}
+	if response.Halt {
+		fmt.Printf("  Halt: %s\n", response.StopReason)
+	}
+	for _, evalErr := range response.Errors {
+		fmt.Printf("  Condition Error: %s\n", evalErr)
+	}
+	for _, skipped := range response.Skipped {
+		fmt.Printf("  Skipped (dry run): %s\n", skipped)
+	}
+	if state.Dirty() {
+		stateJSON, _ := json.Marshal(state.Values)
+		fmt.Printf("  Session State (not saved): %s\n", string(stateJSON))
+	}
+	if output := response.HookOutput(); output != nil {
+		outputJSON, _ := json.MarshalIndent(output, "  ", "  ")
+		fmt.Printf("  Hook Output: %s\n", string(outputJSON))
+	}
 
 	os.Exit(response.ExitCode)
 }
 
 func cmdConfigShow() {
-	homeDir, _ := os.UserHomeDir()
-	configPaths := []string{
-		homeDir + "/.claude-hooks",
-		homeDir + "/.claude",
-	}
-
-	if projectDir := os.Getenv("CLAUDE_PROJECT_DIR"); projectDir != "" {
-		configPaths = append(configPaths, projectDir+"/.claude")
+	configPaths, err := config.SearchPaths(os.Getenv("CLAUDE_PROJECT_DIR"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to resolve config paths: %v\n", err)
+		os.Exit(1)
 	}
 
 	fmt.Println()
@@ -199,9 +349,8 @@ func cmdConfigShow() {
 		fmt.Printf("%d. [%s] %s\n", i+1, status, path)
 
 		if exists {
-			yamlFiles := []string{"rules.yaml", "hooks.yaml", "conditions.yaml", "actions.yaml"}
-			for _, yamlFile := range yamlFiles {
-				filePath := path + "/" + yamlFile
+			for _, yamlFile := range config.ConfigFiles {
+				filePath := filepath.Join(path, yamlFile)
 				if info, err := os.Stat(filePath); err == nil {
 					fmt.Printf("       └─ %s (%d bytes)\n", yamlFile, info.Size())
 				}
@@ -225,6 +374,407 @@ func cmdConfigShow() {
 	if cfg.ScriptsDir != "" {
 		fmt.Printf("  Scripts: %s\n", cfg.ScriptsDir)
 	}
+	fmt.Printf("  Env allowlist: %s\n", strings.Join(cfg.Settings.Env.Allow, ", "))
+	if logger := audit.NewLogger(cfg.Settings.Audit); logger != nil {
+		fmt.Printf("  Decision log: %s (rotate at %d MB, keep %d)\n", logger.Path, logger.MaxSize>>20, logger.MaxFiles)
+	} else {
+		fmt.Println("  Decision log: disabled")
+	}
+	if cfg.Integrity.Manifest != "" {
+		fmt.Printf("  Integrity: %s (on drift: %s, %d drifted files)\n",
+			cfg.Integrity.Manifest, cfg.Integrity.OnDrift, len(cfg.Integrity.Drift))
+		for _, layer := range cfg.Integrity.Refused {
+			fmt.Printf("    ✗ refused layer: %s\n", layer)
+		}
+	}
+}
+
+func cmdConfigLock(args []string) {
+	onDrift := config.OnDriftRefuse
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--on-drift" && i+1 < len(args) {
+			i++
+			onDrift = args[i]
+			continue
+		}
+		fmt.Println("Usage: hookctl config lock [--on-drift refuse|deny|ask]")
+		os.Exit(1)
+	}
+
+	configPaths, err := config.SearchPaths(os.Getenv("CLAUDE_PROJECT_DIR"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to resolve config paths: %v\n", err)
+		os.Exit(1)
+	}
+
+	manifest, err := config.BuildManifest(configPaths, onDrift)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to build manifest: %v\n", err)
+		os.Exit(1)
+	}
+
+	path := config.ManifestPath()
+	if err := config.WriteManifest(path, manifest); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write manifest: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Locked %d files in %d layers (on drift: %s)\n", len(manifest.Files), len(manifest.Layers), onDrift)
+	fmt.Printf("  Manifest: %s\n", path)
+}
+
+func cmdConfigVerify() {
+	path := config.ManifestPath()
+	manifest, err := config.ReadManifest(path)
+	if err != nil {
+		fmt.Printf("✗ %v\n", err)
+		os.Exit(1)
+	}
+	if manifest == nil {
+		fmt.Printf("✗ No manifest at %s (run 'hookctl config lock')\n", path)
+		os.Exit(1)
+	}
+
+	configPaths, err := config.SearchPaths(os.Getenv("CLAUDE_PROJECT_DIR"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to resolve config paths: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Manifest: %s (locked %s, on drift: %s)\n", path, manifest.Created.Format("2006-01-02 15:04"), manifest.OnDrift)
+
+	drift := manifest.Verify(configPaths)
+	if len(drift) == 0 {
+		fmt.Printf("✓ %d files match the manifest\n", len(manifest.Files))
+		return
+	}
+
+	fmt.Println("DRIFT:")
+	for _, d := range drift {
+		fmt.Printf("  ✗ %-9s %s\n", d.Kind, d.File)
+	}
+	os.Exit(1)
+}
+
+func cmdSpoolFlush(args []string) {
+	timeout := 5 * time.Second
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--timeout" && i+1 < len(args) {
+			i++
+			ms, err := strconv.Atoi(args[i])
+			if err != nil || ms <= 0 {
+				fmt.Println("--timeout takes milliseconds")
+				os.Exit(1)
+			}
+			timeout = time.Duration(ms) * time.Millisecond
+			continue
+		}
+		fmt.Println("Usage: hookctl spool flush [--timeout MS]")
+		os.Exit(1)
+	}
+
+	spool := webhook.Spool{Dir: webhook.DefaultSpoolDir()}
+	os.Exit(flushSpool(os.Stdout, spool, &http.Client{}, timeout))
+}
+
+// flushSpool retries a spool's deliveries, reports each one to w and
+// returns the exit status: 1 when any delivery still fails
+func flushSpool(w io.Writer, spool webhook.Spool, client *http.Client, timeout time.Duration) int {
+	results, err := spool.Flush(client, timeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read spool: %v\n", err)
+		return 1
+	}
+	if len(results) == 0 {
+		fmt.Fprintf(w, "✓ Spool is empty (%s)\n", spool.Dir)
+		return 0
+	}
+
+	failed := 0
+	for _, result := range results {
+		name := filepath.Base(result.File)
+		switch {
+		case result.Err == nil:
+			fmt.Fprintf(w, "  ✓ %s → %s\n", name, result.Delivery.URL)
+		case result.Delivery == nil:
+			failed++
+			fmt.Fprintf(w, "  ✗ %s: %v\n", name, result.Err)
+		default:
+			failed++
+			fmt.Fprintf(w, "  ✗ %s → %s: %v (%d attempts)\n", name, result.Delivery.URL, result.Err, result.Delivery.Attempts)
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Delivered %d of %d\n", len(results)-failed, len(results))
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// cmdTicket runs a ticket lifecycle command. Exit status 1 means nothing
+// was changed (usage, refusal or a first step that failed); 2 means a
+// later step failed and the earlier ones are in place.
+func cmdTicket(action string, args []string) {
+	usage := map[string]string{
+		"activate": "hookctl ticket activate <tickets/queue/TICKET-id.md> [project] [--dry-run] [--no-push] [--base BRANCH]",
+		"complete": "hookctl ticket complete [ticket-path] [--dry-run] [--no-push]",
+		"cleanup":  "hookctl ticket cleanup <branch> [--dry-run] [--no-push] [--base BRANCH]",
+	}
+	if usage[action] == "" {
+		fmt.Println("Unknown ticket command:", action)
+		os.Exit(1)
+	}
+
+	opts := ticket.DefaultOptions()
+	dryRun := false
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--dry-run":
+			dryRun = true
+		case args[i] == "--no-push":
+			opts.Push = false
+		case args[i] == "--base" && i+1 < len(args):
+			i++
+			opts.Base = args[i]
+		case args[i] == "--help" || args[i] == "-h":
+			fmt.Println("Usage:", usage[action])
+			return
+		case strings.HasPrefix(args[i], "-"):
+			fmt.Println("Usage:", usage[action])
+			os.Exit(1)
+		default:
+			positional = append(positional, args[i])
+		}
+	}
+
+	cwd, _ := os.Getwd()
+	var plan *ticket.Plan
+	var err error
+	switch {
+	case action == "activate" && (len(positional) == 1 || len(positional) == 2):
+		if len(positional) == 2 {
+			opts.Project = positional[1]
+		}
+		plan, err = ticket.PlanActivate(positional[0], opts)
+	case action == "complete" && len(positional) <= 1:
+		path := ""
+		if len(positional) == 1 {
+			path = positional[0]
+		}
+		plan, err = ticket.PlanComplete(cwd, path, opts)
+	case action == "cleanup" && len(positional) == 1:
+		plan, err = ticket.PlanCleanup(cwd, positional[0], opts)
+	default:
+		fmt.Println("Usage:", usage[action])
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "✗ %s: %v\n", action, err)
+		os.Exit(1)
+	}
+
+	title := plan.TicketID
+	if title == "" {
+		title = plan.Branch
+	}
+	if dryRun {
+		fmt.Printf("Would %s %s:\n", action, title)
+	} else {
+		fmt.Printf("%s %s:\n", strings.ToUpper(action[:1])+action[1:], title)
+	}
+	for _, note := range plan.Notes {
+		fmt.Printf("  · %s\n", note)
+	}
+	if dryRun {
+		for _, step := range plan.Steps {
+			fmt.Printf("  → %s\n", step.Description)
+		}
+		return
+	}
+
+	done, err := plan.Execute(func(step ticket.Step, err error) {
+		if err != nil {
+			fmt.Printf("  ✗ %s: %v\n", step.Description, err)
+			return
+		}
+		fmt.Printf("  ✓ %s\n", step.Description)
+	})
+	if err != nil {
+		if done == 0 {
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Stopped after %d of %d steps; the completed steps were not undone\n", done, len(plan.Steps))
+		os.Exit(2)
+	}
+
+	fmt.Println()
+	switch action {
+	case "activate":
+		fmt.Printf("Next: cd %s, do the work, then run 'hookctl ticket complete'\n", plan.Worktree)
+	case "complete":
+		fmt.Printf("Next: gh pr create --base %s; after the merge run 'hookctl ticket cleanup %s'\n", opts.Base, plan.Branch)
+	}
+}
+
+// cmdTicketLint audits the tickets tree of the current working tree, or of
+// dir. Exits 1 when anything is found.
+func cmdTicketLint(args []string) {
+	asJSON := false
+	root := ""
+	for _, arg := range args {
+		switch {
+		case arg == "--json":
+			asJSON = true
+		case !strings.HasPrefix(arg, "-") && root == "":
+			root = arg
+		default:
+			fmt.Println("Usage: hookctl ticket lint [--json] [dir]")
+			os.Exit(1)
+		}
+	}
+	if root == "" {
+		root, _ = os.Getwd()
+		if state, ok := gitstate.Read(root); ok {
+			root = state.Root
+		}
+	}
+
+	report, err := ticket.Lint(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if asJSON {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		printLintReport(report)
+	}
+	if len(report.Findings) > 0 {
+		os.Exit(1)
+	}
+}
+
+func printLintReport(report *ticket.LintReport) {
+	if report.Tickets == 0 {
+		fmt.Printf("No tickets under %s\n", ticket.Dir(report.Root))
+		return
+	}
+	files := 0
+	last := ""
+	for _, finding := range report.Findings {
+		if finding.Path != last {
+			if last != "" {
+				fmt.Println()
+			}
+			fmt.Println(finding.Path)
+			last = finding.Path
+			files++
+		}
+		fmt.Printf("  ✗ %-18s %s\n", finding.Check, finding.Message)
+	}
+	if len(report.Findings) > 0 {
+		fmt.Println()
+		fmt.Printf("%d findings in %d of %d tickets\n", len(report.Findings), files, report.Tickets)
+		return
+	}
+	fmt.Printf("✓ %d tickets, no findings\n", report.Tickets)
+}
+
+// statuslineInput is the part of Claude Code's status line JSON used
+type statuslineInput struct {
+	SessionID string `json:"session_id"`
+	Cwd       string `json:"cwd"`
+	Workspace struct {
+		CurrentDir string `json:"current_dir"`
+	} `json:"workspace"`
+}
+
+// cmdStatusline prints "project:branch | agent | ticket" for Claude Code's
+// statusLine command. The session and directory come from the status line
+// JSON on stdin, or the arguments. The agent is the latest of the
+// session's active subagents (+N when others run in parallel); parts that
+// don't apply are left out, and it never fails.
+func cmdStatusline(args []string) {
+	var input statuslineInput
+	if info, err := os.Stdin.Stat(); len(args) == 0 && err == nil && info.Mode()&os.ModeCharDevice == 0 {
+		// A stdin nobody writes to must not hang the status line
+		read := make(chan statuslineInput, 1)
+		go func() {
+			var in statuslineInput
+			json.NewDecoder(os.Stdin).Decode(&in)
+			read <- in
+		}()
+		select {
+		case input = <-read:
+		case <-time.After(time.Second):
+		}
+	}
+	dir := input.Workspace.CurrentDir
+	if dir == "" {
+		dir = input.Cwd
+	}
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--session" && i+1 < len(args):
+			i++
+			input.SessionID = args[i]
+		case !strings.HasPrefix(args[i], "-"):
+			dir = args[i]
+		default:
+			fmt.Println("Usage: hookctl statusline [--session ID] [dir]")
+			os.Exit(1)
+		}
+	}
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+
+	var parts []string
+	state, ok := gitstate.Read(dir)
+	switch {
+	case !ok:
+		parts = append(parts, filepath.Base(dir))
+	case state.Branch != "":
+		parts = append(parts, state.Project+":"+state.Branch)
+	default:
+		parts = append(parts, state.Project+":"+shortHead(state.Head))
+	}
+
+	if input.SessionID != "" {
+		current, release := session.NewStore(session.DefaultDir(), false).Acquire(input.SessionID)
+		agents := current.Agents()
+		release()
+		if n := len(agents); n > 0 {
+			agent := agents[n-1].Name
+			if n > 1 {
+				agent += fmt.Sprintf(" +%d", n-1)
+			}
+			parts = append(parts, agent)
+		}
+	}
+
+	if ok && state.Branch != "" {
+		if t, _ := ticket.ForBranch(state.Root, state.Branch); t != nil {
+			id := t.ID
+			if id == "" {
+				id = strings.TrimSuffix(filepath.Base(t.Path), ".md")
+			}
+			parts = append(parts, id)
+		}
+	}
+	fmt.Println(strings.Join(parts, " | "))
+}
+
+func shortHead(head string) string {
+	if len(head) > 7 {
+		return head[:7]
+	}
+	if head == "" {
+		return "HEAD"
+	}
+	return head
 }
 
 func cmdConfigValidate() {
@@ -259,6 +809,67 @@ func cmdConfigValidate() {
 		}
 	}
 
+	// Check on_error policies
+	if cfg.Settings.OnError != "" && !config.ValidOnError(cfg.Settings.OnError) {
+		errors = append(errors, fmt.Sprintf("Settings on_error must be allow, deny or ask, not %q", cfg.Settings.OnError))
+	}
+	for _, rule := range cfg.Rules {
+		if rule.OnError != "" && !config.ValidOnError(rule.OnError) {
+			errors = append(errors, fmt.Sprintf("Rule '%s' on_error must be allow, deny or ask, not %q", rule.ID, rule.OnError))
+		}
+	}
+
+	// Check patterns and scripts, which would otherwise fail at evaluation
+	names := make([]string, 0, len(cfg.Conditions))
+	for name := range cfg.Conditions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cond := cfg.Conditions[name]
+		checkConditionDefinition(&cond, cfg, fmt.Sprintf("Condition '%s'", name), &errors)
+	}
+	for _, rule := range cfg.Rules {
+		checkConditionDefinition(rule.Conditions, cfg, fmt.Sprintf("Rule '%s'", rule.ID), &errors)
+	}
+
+	// Check custom secret patterns
+	if _, err := secrets.NewScanner(secrets.ConfigPatterns(cfg), false); err != nil {
+		errors = append(errors, fmt.Sprintf("Redaction: %v", err))
+	}
+
+	// Check agent markers, which must name the agent with a capture group,
+	// and role stages
+	if err := conditions.CheckAgentMarkers(cfg.Settings.Agents.Markers); err != nil {
+		errors = append(errors, fmt.Sprintf("Agents: %v", err))
+	}
+	roles := make([]string, 0, len(cfg.Settings.Agents.Roles))
+	for name := range cfg.Settings.Agents.Roles {
+		roles = append(roles, name)
+	}
+	sort.Strings(roles)
+	for _, name := range roles {
+		if stage := cfg.Settings.Agents.Roles[name].Stage; !cycle.ValidStage(stage) {
+			errors = append(errors, fmt.Sprintf("Agents: role %s stage must be %s, not %q",
+				name, strings.Join(cycle.Stages, ", "), stage))
+		}
+	}
+
+	// Check message templates. Undefined variables render empty, so they
+	// are warnings; templates that don't parse are errors.
+	for _, rule := range cfg.Rules {
+		for i := range rule.Actions {
+			for _, issue := range actions.TemplateIssues(&rule.Actions[i], cfg) {
+				message := fmt.Sprintf("Rule '%s' action %d %s", rule.ID, i+1, issue)
+				if strings.Contains(issue, "undefined variable") {
+					warnings = append(warnings, message)
+				} else {
+					errors = append(errors, message)
+				}
+			}
+		}
+	}
+
 	// Print results
 	if len(errors) > 0 {
 		fmt.Println("ERRORS:")
@@ -296,6 +907,10 @@ func checkConditionRefs(cond *config.Condition, available map[string]config.Cond
 		}
 	}
 
+	if cond.Type == "builtin" && !isBuiltin(cond.Builtin) {
+		*errors = append(*errors, fmt.Sprintf("Rule '%s' uses unknown builtin condition: %s", ruleID, cond.Builtin))
+	}
+
 	for _, c := range cond.All {
 		checkConditionRefs(&c, available, ruleID, errors)
 	}
@@ -307,6 +922,55 @@ func checkConditionRefs(cond *config.Condition, available map[string]config.Cond
 	}
 }
 
+// checkConditionDefinition reports regexes and globs that don't compile
+// and script conditions whose script is missing
+func checkConditionDefinition(cond *config.Condition, cfg *config.Config, where string, errors *[]string) {
+	if cond == nil {
+		return
+	}
+	switch cond.Type {
+	case "regex":
+		if _, err := regexp.Compile(cond.Pattern); err != nil {
+			*errors = append(*errors, fmt.Sprintf("%s has an invalid regex: %v", where, err))
+		}
+	case "glob":
+		if _, err := filepath.Match(cond.Pattern, ""); err != nil {
+			*errors = append(*errors, fmt.Sprintf("%s has an invalid glob %q: %v", where, cond.Pattern, err))
+		}
+	case "script":
+		script := cond.Script
+		if homeDir, err := os.UserHomeDir(); err == nil && strings.HasPrefix(script, "~/") {
+			script = filepath.Join(homeDir, script[2:])
+		}
+		if !filepath.IsAbs(script) {
+			// Relative scripts only resolve inside a scripts directory
+			script = ""
+			if cfg.ScriptsDir != "" && cond.Script != "" {
+				script = filepath.Join(cfg.ScriptsDir, cond.Script)
+			}
+		}
+		if info, err := os.Stat(script); err != nil || info.Mode()&0111 == 0 {
+			*errors = append(*errors, fmt.Sprintf("%s script is missing or not executable: %s", where, cond.Script))
+		}
+	}
+	for i := range cond.All {
+		checkConditionDefinition(&cond.All[i], cfg, where, errors)
+	}
+	for i := range cond.Any {
+		checkConditionDefinition(&cond.Any[i], cfg, where, errors)
+	}
+	checkConditionDefinition(cond.Not, cfg, where, errors)
+}
+
+func isBuiltin(name string) bool {
+	for _, builtin := range conditions.BuiltinNames() {
+		if builtin == name {
+			return true
+		}
+	}
+	return false
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
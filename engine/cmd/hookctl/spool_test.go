@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/webhook"
+)
+
+func TestFlushSpool(t *testing.T) {
+	up := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	spool := webhook.Spool{Dir: t.TempDir()}
+
+	var out strings.Builder
+	if code := flushSpool(&out, spool, server.Client(), time.Second); code != 0 || !strings.Contains(out.String(), "Spool is empty") {
+		t.Fatalf("empty spool: exit %d, output %q", code, out.String())
+	}
+
+	if err := spool.Save(&webhook.Delivery{URL: server.URL, Body: "{}", Created: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	up = false
+	out.Reset()
+	if code := flushSpool(&out, spool, server.Client(), time.Second); code != 1 || !strings.Contains(out.String(), "Delivered 0 of 1") {
+		t.Fatalf("server down: exit %d, output %q", code, out.String())
+	}
+
+	up = true
+	out.Reset()
+	if code := flushSpool(&out, spool, server.Client(), time.Second); code != 0 || !strings.Contains(out.String(), "Delivered 1 of 1") {
+		t.Fatalf("server up: exit %d, output %q", code, out.String())
+	}
+	if files, _ := spool.Pending(); len(files) != 0 {
+		t.Errorf("delivered files still spooled: %v", files)
+	}
+}
@@ -0,0 +1,84 @@
+package actions
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/conditions"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/cycle"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/session"
+)
+
+// TestExecuteCycleRecordsPromptAgent dispatches agents the way the
+// require-agent-for-edits message says to: a general-purpose Task whose
+// prompt names the agent
+func TestExecuteCycleRecordsPromptAgent(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Settings.Agents = config.AgentSettings{
+		Markers: []string{`working as the ([A-Za-z0-9_-]+) agent`},
+		Roles: map[string]config.AgentRole{
+			"code-developer": {Cycle: "code", Stage: cycle.Creator},
+			"code-reviewer":  {Cycle: "code", Stage: cycle.Critic},
+		},
+	}
+	state, release := session.NewStore(t.TempDir(), false).Acquire("s1")
+	defer release()
+
+	calls := []struct {
+		hook     string
+		agent    string
+		response string
+	}{
+		{"PreToolUse", "code-developer", ""},
+		{"PostToolUse", "code-developer", "Done."},
+		{"PreToolUse", "code-reviewer", ""},
+		{"PostToolUse", "code-reviewer", "Looks good. APPROVED"},
+	}
+	for i, call := range calls {
+		data, err := json.Marshal(map[string]any{
+			"hook_event_name": call.hook,
+			"session_id":      "s1",
+			"tool_name":       "Task",
+			"tool_use_id":     "toolu_" + call.agent,
+			"tool_input": map[string]any{
+				"subagent_type": "general-purpose",
+				"description":   "[wg:feature] " + call.agent + " - work",
+				"prompt":        "You are working as the " + call.agent + " agent ...",
+			},
+			"tool_response": call.response,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		event, err := conditions.NewEvent(data, map[string]string{}, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		event.AttachSession(state)
+		event.Raw["ticket"] = map[string]any{"path": "/work/wg/tickets/active/TICKET-s1-001.md"}
+
+		executeSubagent(&config.Action{Type: "subagent"}, event, cfg)
+		executeCycle(&config.Action{Type: "cycle"}, event, cfg)
+
+		if agents := state.Agents(); call.hook == "PreToolUse" && (len(agents) != 1 || agents[0].Name != call.agent) {
+			t.Errorf("call %d: active agents = %+v, want %s", i, agents, call.agent)
+		}
+	}
+
+	history := cycle.History(state.Values, "TICKET-s1-001")
+	if len(history) != len(calls) {
+		t.Fatalf("recorded %d transitions, want %d: %+v", len(history), len(calls), history)
+	}
+	for i, call := range calls {
+		if history[i].Agent != call.agent {
+			t.Errorf("transition %d agent = %q, want %q", i, history[i].Agent, call.agent)
+		}
+	}
+	if status := cycle.Evaluate(history); !status.Approved || status.Pending != cycle.Expediter {
+		t.Errorf("status = %+v, want approved and pending expediter", status)
+	}
+	if len(state.Agents()) != 0 {
+		t.Errorf("agents left on the stack: %+v", state.Agents())
+	}
+}
@@ -5,63 +5,137 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/dandoyle-pdm/workflow-guard/engine/internal/conditions"
 	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/cycle"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/session"
 )
 
 // Response represents a hook response
 type Response struct {
-	ExitCode int
-	Decision string // allow, deny, ask
-	Message  string
+	ExitCode int    `json:"exit_code"`
+	Decision string `json:"decision,omitempty"` // allow, deny, ask
+	Message  string `json:"message,omitempty"`
+	// HookEvent is the event type the response answers, which decides how
+	// it is serialized
+	HookEvent string `json:"hook_event,omitempty"`
+	// AdditionalContext is injected into the model's context
+	// (PostToolUse, UserPromptSubmit and SessionStart)
+	AdditionalContext []string `json:"additional_context,omitempty"`
+	// SystemMessages are shown to the user
+	SystemMessages []string `json:"system_messages,omitempty"`
+	// SuppressOutput hides the hook's output from the transcript
+	SuppressOutput bool `json:"suppress_output,omitempty"`
+	// Halt stops Claude entirely (continue: false) with StopReason shown
+	// to the user
+	Halt       bool   `json:"halt,omitempty"`
+	StopReason string `json:"stop_reason,omitempty"`
+	// MatchedRules lists the rules whose conditions matched, in evaluation
+	// order, for the decision log
+	MatchedRules []string `json:"matched_rules,omitempty"`
+	// Errors lists conditions that could not be evaluated, for the
+	// decision log
+	Errors []string `json:"errors,omitempty"`
+	// Skipped lists the actions a dry run did not execute
+	Skipped []string `json:"skipped,omitempty"`
 }
 
-// Execute executes an action and returns a response if terminal
-func Execute(action *config.Action, event *conditions.HookEvent, cfg *config.Config) *Response {
+// Merge copies the non-terminal output accumulated in other into r
+func (r *Response) Merge(other *Response) {
+	if other == nil || other == r {
+		return
+	}
+	r.AdditionalContext = append(r.AdditionalContext, other.AdditionalContext...)
+	r.SystemMessages = append(r.SystemMessages, other.SystemMessages...)
+	r.SuppressOutput = r.SuppressOutput || other.SuppressOutput
+	r.MatchedRules = append(r.MatchedRules, other.MatchedRules...)
+	r.Errors = append(r.Errors, other.Errors...)
+	r.Skipped = append(r.Skipped, other.Skipped...)
+}
+
+// Execute executes an action and returns a response if terminal.
+// Non-terminal output (context, notifications) accumulates onto out. An
+// error means the condition of a conditional action could not be
+// evaluated; the rule's on_error decides what follows.
+func Execute(action *config.Action, event *conditions.HookEvent, cfg *config.Config, out *Response) (*Response, error) {
 	if action == nil {
-		return nil
+		return nil, nil
 	}
 
 	// Handle action reference
 	if action.Ref != "" {
 		refAction, exists := cfg.Actions[action.Ref]
 		if !exists {
-			return nil
+			return nil, nil
 		}
-		// Merge params if provided
+		// Merge params if provided. The config is shared between
+		// dispatches, so never write into its params maps.
 		merged := refAction
+		if action.Suggest != nil {
+			merged.Suggest = action.Suggest
+		}
 		if len(action.Params) > 0 {
-			if merged.Params == nil {
-				merged.Params = make(map[string]any)
-			}
+			merged.Params = copyParams(refAction.Params)
 			for k, v := range action.Params {
 				merged.Params[k] = v
 			}
 		}
-		return Execute(&merged, event, cfg)
+		return Execute(&merged, event, cfg, out)
+	}
+
+	if action.SuppressOutput {
+		out.SuppressOutput = true
+	}
+
+	if event.DryRun {
+		if target, ok := sideEffect(action, event); ok {
+			out.Skipped = append(out.Skipped, action.Type+" "+target)
+			return nil, nil
+		}
 	}
 
 	switch action.Type {
 	case "decision":
-		return executeDecision(action, event)
+		return executeDecision(action, event, cfg), nil
 	case "log":
-		return executeLog(action, event)
+		return executeLog(action, event, cfg), nil
+	case "halt":
+		return executeHalt(action, event, cfg), nil
+	case "state":
+		return executeState(action, event, cfg), nil
+	case "cycle":
+		return executeCycle(action, event, cfg), nil
+	case "subagent":
+		return executeSubagent(action, event, cfg), nil
+	case "context":
+		return executeContext(action, event, cfg, out), nil
+	case "notify":
+		return executeNotify(action, event, cfg, out), nil
+	case "webhook":
+		return executeWebhook(action, event, cfg), nil
 	case "chain":
-		return executeChain(action, event, cfg)
+		return executeChain(action, event, cfg, out)
 	case "conditional":
-		return executeConditional(action, event, cfg)
+		return executeConditional(action, event, cfg, out)
 	default:
-		return nil
+		return nil, nil
 	}
 }
 
-func executeDecision(action *config.Action, event *conditions.HookEvent) *Response {
+func executeDecision(action *config.Action, event *conditions.HookEvent, cfg *config.Config) *Response {
 	decision := action.Decision
-	message := renderTemplate(action.Message, event, action.Params)
+	message := renderTemplate(action.Message, event, action.Params, cfg)
+	if decision != "allow" {
+		if hint := renderSuggestion(action.Suggest, event, action.Params); hint != "" {
+			if message = strings.TrimRight(message, "\n"); message != "" {
+				message += "\n\n"
+			}
+			message += Redact(hint, cfg)
+		}
+	}
 
 	resp := &Response{}
 	switch decision {
@@ -83,13 +157,30 @@ func executeDecision(action *config.Action, event *conditions.HookEvent) *Respon
 	return resp
 }
 
-func executeLog(action *config.Action, event *conditions.HookEvent) *Response {
-	logFile := "~/.claude/logs/hooks.jsonl"
+// sideEffect returns what an action writes to outside the response: the
+// file of a log action, the URL of a webhook
+func sideEffect(action *config.Action, event *conditions.HookEvent) (string, bool) {
+	switch action.Type {
+	case "log":
+		return logPath(action), true
+	case "webhook":
+		return webhookURL(action, event), true
+	}
+	return "", false
+}
+
+// logPath returns the file a log action appends to
+func logPath(action *config.Action) string {
 	if action.Params != nil {
 		if lf, ok := action.Params["log_file"].(string); ok {
-			logFile = lf
+			return lf
 		}
 	}
+	return "~/.claude/logs/hooks.jsonl"
+}
+
+func executeLog(action *config.Action, event *conditions.HookEvent, cfg *config.Config) *Response {
+	logFile := logPath(action)
 
 	// Expand home directory
 	if strings.HasPrefix(logFile, "~/") {
@@ -110,7 +201,7 @@ func executeLog(action *config.Action, event *conditions.HookEvent) *Response {
 		"timestamp":  time.Now().Format(time.RFC3339),
 		"event_type": event.HookType,
 		"tool_name":  event.ToolName,
-		"tool_input": event.ToolInput,
+		"tool_input": RedactValue(event.ToolInput, cfg),
 		"session_id": event.SessionID,
 	}
 
@@ -128,13 +219,102 @@ func executeLog(action *config.Action, event *conditions.HookEvent) *Response {
 	return nil // Non-terminal action
 }
 
-func executeChain(action *config.Action, event *conditions.HookEvent, cfg *config.Config) *Response {
+func executeHalt(action *config.Action, event *conditions.HookEvent, cfg *config.Config) *Response {
+	return &Response{
+		ExitCode:   0,
+		Halt:       true,
+		StopReason: renderTemplate(action.Message, event, action.Params, cfg),
+	}
+}
+
+func executeState(action *config.Action, event *conditions.HookEvent, cfg *config.Config) *Response {
+	if event.Session == nil {
+		return nil
+	}
+	for key, value := range action.Set {
+		if text, ok := value.(string); ok {
+			value = renderTemplate(text, event, action.Params, cfg)
+		}
+		event.Session.Set(key, value)
+	}
+	for _, key := range action.Unset {
+		event.Session.Delete(key)
+	}
+	return nil // Non-terminal action
+}
+
+// executeCycle records the quality-cycle transition of a Task call in
+// session state: the dispatched agent (HookEvent.TaskAgent) starting
+// (PreToolUse) or returning with the verdict of its report (PostToolUse).
+// Agents without a stage in agents.roles, and branches without a ticket,
+// are not recorded.
+func executeCycle(action *config.Action, event *conditions.HookEvent, cfg *config.Config) *Response {
+	if event.Session == nil || event.ToolName != "Task" {
+		return nil
+	}
+	name, _ := event.TaskAgent()
+	role, known := cfg.Settings.Agents.Roles[name]
+	ticketPath, _ := event.Field("ticket.path").(string)
+	if !known || role.Stage == "" || ticketPath == "" {
+		return nil
+	}
+
+	t := cycle.Transition{Agent: name, Cycle: role.Cycle, Stage: role.Stage, Event: cycle.Start, At: time.Now().UTC()}
+	if event.HookType == "PostToolUse" {
+		t.Event = cycle.Finish
+		t.Verdict = cycle.Verdict(cycle.Report(event.ToolResponse))
+	}
+	cycle.Record(event.Session, cycle.Key(ticketPath), t)
+	return nil // Non-terminal action
+}
+
+// executeSubagent keeps the session's stack of active subagents: a Task's
+// agent is pushed on PreToolUse and popped when the call returns
+func executeSubagent(action *config.Action, event *conditions.HookEvent, cfg *config.Config) *Response {
+	if event.Session == nil || event.ToolName != "Task" {
+		return nil
+	}
+	name, _ := event.TaskAgent()
+	if name == "" {
+		name = "general-purpose" // Task's default agent
+	}
+	switch event.HookType {
+	case "PreToolUse":
+		description, _ := event.ToolInput["description"].(string)
+		event.Session.PushAgent(session.Agent{
+			Name:        name,
+			Description: description,
+			ToolUseID:   event.ToolUseID,
+			Started:     time.Now().UTC(),
+		})
+	case "PostToolUse":
+		event.Session.PopAgent(event.ToolUseID, name)
+	}
+	return nil // Non-terminal action
+}
+
+func executeContext(action *config.Action, event *conditions.HookEvent, cfg *config.Config, out *Response) *Response {
+	if text := renderTemplate(action.Message, event, action.Params, cfg); strings.TrimSpace(text) != "" {
+		out.AdditionalContext = append(out.AdditionalContext, text)
+	}
+	return nil // Non-terminal action
+}
+
+func executeNotify(action *config.Action, event *conditions.HookEvent, cfg *config.Config, out *Response) *Response {
+	if text := renderTemplate(action.Message, event, action.Params, cfg); strings.TrimSpace(text) != "" {
+		out.SystemMessages = append(out.SystemMessages, text)
+	}
+	return nil // Non-terminal action
+}
+
+func executeChain(action *config.Action, event *conditions.HookEvent, cfg *config.Config, out *Response) (*Response, error) {
 	for _, subAction := range action.Actions {
+		if subAction.Suggest == nil {
+			subAction.Suggest = action.Suggest
+		}
 		// Merge parent params into sub-action params
 		if len(action.Params) > 0 {
-			if subAction.Params == nil {
-				subAction.Params = make(map[string]any)
-			}
+			subAction.Params = copyParams(subAction.Params)
 			for k, v := range action.Params {
 				// Only add if not already present (sub-action params take precedence)
 				if _, exists := subAction.Params[k]; !exists {
@@ -143,64 +323,34 @@ func executeChain(action *config.Action, event *conditions.HookEvent, cfg *confi
 			}
 		}
 
-		resp := Execute(&subAction, event, cfg)
-		if resp != nil {
-			return resp // First terminal action wins
+		resp, err := Execute(&subAction, event, cfg, out)
+		if resp != nil || err != nil {
+			return resp, err // First terminal action wins
 		}
 	}
-	return nil
+	return nil, nil
 }
 
-func executeConditional(action *config.Action, event *conditions.HookEvent, cfg *config.Config) *Response {
+func executeConditional(action *config.Action, event *conditions.HookEvent, cfg *config.Config, out *Response) (*Response, error) {
 	if action.Condition != nil {
-		matches := conditions.Evaluate(action.Condition, event, cfg)
+		matches, err := conditions.Evaluate(action.Condition, event, cfg)
+		if err != nil {
+			// Neither branch is known to apply
+			return nil, fmt.Errorf("conditional action: %w", err)
+		}
 		if matches && action.Then != nil {
-			return Execute(action.Then, event, cfg)
+			return Execute(action.Then, event, cfg, out)
 		} else if !matches && action.Else != nil {
-			return Execute(action.Else, event, cfg)
+			return Execute(action.Else, event, cfg, out)
 		}
 	}
-	return nil
+	return nil, nil
 }
 
-func renderTemplate(template string, event *conditions.HookEvent, params map[string]any) string {
-	result := template
-
-	// Build context from event and params
-	context := make(map[string]string)
-	context["tool_name"] = event.ToolName
-	context["session_id"] = event.SessionID
-
-	// Add tool_input fields
-	for k, v := range event.ToolInput {
-		context[k] = fmt.Sprintf("%v", v)
-	}
-
-	// Add params (override if same key)
+func copyParams(params map[string]any) map[string]any {
+	result := make(map[string]any, len(params))
 	for k, v := range params {
-		context[k] = fmt.Sprintf("%v", v)
-	}
-
-	// Replace {{variable}} with values
-	// Do multiple passes to handle nested templates
-	for pass := 0; pass < 3; pass++ {
-		changed := false
-		for key, value := range context {
-			placeholder := fmt.Sprintf("{{%s}}", key)
-			if strings.Contains(result, placeholder) {
-				result = strings.ReplaceAll(result, placeholder, value)
-				changed = true
-			}
-		}
-		if !changed {
-			break
-		}
+		result[k] = v
 	}
-
-	// Remove any remaining unreplaced template variables
-	// This handles cases where a variable doesn't exist in the context
-	re := regexp.MustCompile(`\{\{[^}]+\}\}`)
-	result = re.ReplaceAllString(result, "")
-
 	return result
 }
@@ -0,0 +1,62 @@
+package actions
+
+import "strings"
+
+// Events whose hookSpecificOutput accepts additionalContext
+var contextEvents = map[string]bool{
+	"PostToolUse":      true,
+	"UserPromptSubmit": true,
+	"SessionStart":     true,
+}
+
+// HookOutput builds the JSON object Claude Code expects for the response's
+// event type. It returns nil when there is nothing to report, in which case
+// the dispatcher prints nothing.
+func (r *Response) HookOutput() map[string]any {
+	event := r.HookEvent
+	if event == "" {
+		event = "PreToolUse"
+	}
+
+	output := map[string]any{}
+	specific := map[string]any{}
+
+	switch event {
+	case "PreToolUse":
+		if r.Decision != "" {
+			specific["permissionDecision"] = r.Decision
+			specific["permissionDecisionReason"] = r.Message
+		}
+	default:
+		// Other events can only block, with a reason fed back to the model.
+		// On Stop/SubagentStop this keeps Claude working instead of ending.
+		if r.Decision == "deny" {
+			output["decision"] = "block"
+			output["reason"] = r.Message
+		}
+	}
+
+	if contextEvents[event] && len(r.AdditionalContext) > 0 {
+		specific["additionalContext"] = strings.Join(r.AdditionalContext, "\n\n")
+	}
+	if len(specific) > 0 {
+		specific["hookEventName"] = event
+		output["hookSpecificOutput"] = specific
+	}
+
+	if len(r.SystemMessages) > 0 {
+		output["systemMessage"] = strings.Join(r.SystemMessages, "\n")
+	}
+	if r.SuppressOutput {
+		output["suppressOutput"] = true
+	}
+	if r.Halt {
+		output["continue"] = false
+		output["stopReason"] = r.StopReason
+	}
+
+	if len(output) == 0 {
+		return nil
+	}
+	return output
+}
@@ -0,0 +1,42 @@
+package actions
+
+import (
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/secrets"
+)
+
+// Redact replaces secrets in text with [REDACTED:<detector>]
+func Redact(text string, cfg *config.Config) string {
+	if text == "" {
+		return text
+	}
+	return secrets.ForConfig(cfg).Redact(text)
+}
+
+// RedactValue returns a copy of v with secrets replaced in every string,
+// however deeply nested in maps and lists
+func RedactValue(v any, cfg *config.Config) any {
+	scanner := secrets.ForConfig(cfg)
+	var walk func(v any) any
+	walk = func(v any) any {
+		switch value := v.(type) {
+		case string:
+			return scanner.Redact(value)
+		case map[string]any:
+			result := make(map[string]any, len(value))
+			for k, child := range value {
+				result[k] = walk(child)
+			}
+			return result
+		case []any:
+			result := make([]any, len(value))
+			for i, child := range value {
+				result[i] = walk(child)
+			}
+			return result
+		default:
+			return v
+		}
+	}
+	return walk(v)
+}
@@ -0,0 +1,471 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/conditions"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/shell"
+)
+
+// Files larger than this are not read to build an Edit suggestion
+const maxSuggestFileSize = 1 << 20
+
+// Lines of context used to anchor an append; longer anchors are noise
+const maxAppendAnchorLines = 5
+
+// toolCall is a Write or Edit call equivalent to part of a Bash command
+type toolCall struct {
+	Tool  string
+	Input any
+}
+
+type writeInput struct {
+	FilePath string `json:"file_path"`
+	Content  string `json:"content"`
+}
+
+type editInput struct {
+	FilePath   string `json:"file_path"`
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all,omitempty"`
+}
+
+// renderSuggestion formats a decision's remediation, or returns "" when
+// nothing applies to this event
+func renderSuggestion(suggest *config.Suggestion, event *conditions.HookEvent, params map[string]any) string {
+	if suggest == nil {
+		return ""
+	}
+	var lines []string
+	if suggest.ToolCall {
+		for _, call := range suggestToolCalls(event) {
+			data, err := json.Marshal(call.Input)
+			if err != nil {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("- Use the %s tool with: %s", call.Tool, data))
+		}
+	}
+	if suggest.Command != "" {
+		if command := strings.TrimSpace(render(suggest.Command, event, params, true)); command != "" {
+			lines = append(lines, "- Run instead: "+command)
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "Suggested fix:\n" + strings.Join(lines, "\n")
+}
+
+// suggestToolCalls translates the file writes of a Bash command into Write
+// and Edit calls. Only writes whose content and file are known exactly are
+// translated: anything the shell would expand is left out, and so is a
+// relative path after a cd that may or may not have happened.
+func suggestToolCalls(event *conditions.HookEvent) []toolCall {
+	command, _ := event.ToolInput["command"].(string)
+	if event.ToolName != "Bash" || command == "" {
+		return nil
+	}
+
+	commands := shell.Parse(command)
+	env := event.ShellEnv(event.Cwd)
+	var calls []toolCall
+	for i, cmd := range commands {
+		dir := suggestDir(env, cmd)
+		calls = append(calls, commandWrites(commands, i, dir)...)
+		env.Step(cmd)
+	}
+	return calls
+}
+
+// suggestDir returns the one directory cmd runs in, "" when there may be
+// several or it can't be told
+func suggestDir(env *shell.Env, cmd shell.Command) string {
+	dirs, ok := env.Dirs(cmd)
+	if !ok || len(dirs) != 1 {
+		return ""
+	}
+	return dirs[0]
+}
+
+// commandWrites translates the writes of commands[i], run in dir
+func commandWrites(commands []shell.Command, i int, dir string) []toolCall {
+	cmd := commands[i]
+	var calls []toolCall
+	switch cmd.Name() {
+	case "sed":
+		calls = append(calls, sedEdits(cmd, dir)...)
+	case "tee":
+		if i > 0 && commands[i-1].Separator == "|" {
+			if content, ok := commandOutput(commands[i-1]); ok {
+				calls = append(calls, teeWrites(cmd, content, dir)...)
+			}
+		}
+	}
+
+	content, ok := commandOutput(cmd)
+	if !ok {
+		return calls
+	}
+	for _, r := range cmd.Redirects {
+		if !r.Writes() || r.Op == "<>" || (r.FD != "" && r.FD != "1") {
+			continue
+		}
+		path := suggestPath(r.Target, dir)
+		if path == "" {
+			continue
+		}
+		appending := r.Op == ">>" || r.Op == "&>>"
+		if call := writeCall(path, content, appending); call != nil {
+			calls = append(calls, *call)
+		}
+	}
+	return calls
+}
+
+// commandOutput returns what echo, printf or cat of a heredoc prints
+func commandOutput(cmd shell.Command) (string, bool) {
+	var content string
+	switch cmd.Name() {
+	case "echo":
+		args := cmd.Args[1:]
+		newline, escapes := true, false
+		for len(args) > 0 && isEchoFlags(args[0]) {
+			for _, c := range args[0][1:] {
+				switch c {
+				case 'n':
+					newline = false
+				case 'e':
+					escapes = true
+				case 'E':
+					escapes = false
+				}
+			}
+			args = args[1:]
+		}
+		content = strings.Join(args, " ")
+		if escapes {
+			content = unescapeBackslashes(content)
+		}
+		if newline {
+			content += "\n"
+		}
+
+	case "printf":
+		args := cmd.Args[1:]
+		if len(args) > 0 && args[0] == "--" {
+			args = args[1:]
+		}
+		// Only a plain format string; directives depend on arguments
+		if len(args) != 1 || strings.Contains(strings.ReplaceAll(args[0], "%%", ""), "%") {
+			return "", false
+		}
+		content = strings.ReplaceAll(unescapeBackslashes(args[0]), "%%", "%")
+
+	case "cat":
+		if len(cmd.Args) > 1 && !(len(cmd.Args) == 2 && cmd.Args[1] == "-") {
+			return "", false
+		}
+		found := false
+		for _, r := range cmd.Redirects {
+			switch r.Op {
+			case "<<":
+				content, found = r.Body, true
+			case "<<<":
+				content, found = r.Target+"\n", true
+			}
+		}
+		if !found {
+			return "", false
+		}
+
+	default:
+		return "", false
+	}
+
+	// The parser keeps $VAR and `...` as written; the shell would not
+	if strings.ContainsAny(content, "$`") {
+		return "", false
+	}
+	return content, true
+}
+
+func isEchoFlags(arg string) bool {
+	if len(arg) < 2 || arg[0] != '-' {
+		return false
+	}
+	return strings.Trim(arg[1:], "neE") == ""
+}
+
+func unescapeBackslashes(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\t`, "\t", `\r`, "\r").Replace(s)
+}
+
+// teeWrites builds the calls for "... | tee [-a] file..."
+func teeWrites(cmd shell.Command, content, cwd string) []toolCall {
+	var calls []toolCall
+	appending := false
+	for _, arg := range cmd.Args[1:] {
+		switch {
+		case arg == "-a" || arg == "--append":
+			appending = true
+		case strings.HasPrefix(arg, "-"):
+		default:
+			path := suggestPath(arg, cwd)
+			if path == "" {
+				continue
+			}
+			if call := writeCall(path, content, appending); call != nil {
+				calls = append(calls, *call)
+			}
+		}
+	}
+	return calls
+}
+
+// writeCall writes content to path, or appends it with an Edit anchored on
+// the end of the existing file
+func writeCall(path, content string, appending bool) *toolCall {
+	if !appending {
+		return &toolCall{Tool: "Write", Input: writeInput{FilePath: path, Content: content}}
+	}
+	text, err := readForSuggestion(path)
+	if os.IsNotExist(err) || (err == nil && text == "") {
+		return &toolCall{Tool: "Write", Input: writeInput{FilePath: path, Content: content}}
+	}
+	if err != nil {
+		return nil
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	for n := 1; n <= len(lines) && n <= maxAppendAnchorLines; n++ {
+		old := strings.Join(lines[len(lines)-n:], "")
+		if strings.TrimSpace(old) == "" || strings.Count(text, old) != 1 {
+			continue
+		}
+		return &toolCall{Tool: "Edit", Input: editInput{FilePath: path, OldString: old, NewString: old + content}}
+	}
+	return nil
+}
+
+// sedEdits turns "sed -i s/old/new/[g] file..." into Edit calls when the
+// pattern and replacement are literal text
+func sedEdits(cmd shell.Command, cwd string) []toolCall {
+	var scripts, positional []string
+	inPlace, extended := false, false
+	args := cmd.Args[1:]
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			positional = append(positional, args[i+1:]...)
+			i = len(args)
+		case arg == "--expression" && i+1 < len(args):
+			i++
+			scripts = append(scripts, args[i])
+		case strings.HasPrefix(arg, "--expression="):
+			scripts = append(scripts, strings.TrimPrefix(arg, "--expression="))
+		case arg == "--regexp-extended":
+			extended = true
+		case arg == "--in-place" || strings.HasPrefix(arg, "--in-place="):
+			inPlace = true
+		case strings.HasPrefix(arg, "--"):
+			return nil
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			for j, c := range arg[1:] {
+				stop := false
+				switch c {
+				case 'E', 'r':
+					extended = true
+				case 'i':
+					inPlace, stop = true, true // The rest is a backup suffix
+				case 'e':
+					if rest := arg[j+2:]; rest != "" {
+						scripts = append(scripts, rest)
+					} else if i+1 < len(args) {
+						i++
+						scripts = append(scripts, args[i])
+					}
+					stop = true
+				default:
+					return nil // -n, -s, -z... change what sed does
+				}
+				if stop {
+					break
+				}
+			}
+		case arg == "":
+			// BSD sed -i '' (no backup suffix)
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	if len(scripts) == 0 && len(positional) > 0 {
+		scripts, positional = positional[:1], positional[1:]
+	}
+	if !inPlace || len(scripts) != 1 || len(positional) == 0 {
+		return nil
+	}
+	old, replacement, global, ok := parseSubstitution(scripts[0], extended)
+	if !ok {
+		return nil
+	}
+
+	var calls []toolCall
+	for _, file := range positional {
+		path := suggestPath(file, cwd)
+		if path == "" {
+			continue
+		}
+		replaceAll := global
+		if text, err := readForSuggestion(path); err == nil {
+			count := strings.Count(text, old)
+			if count == 0 {
+				continue // sed would change nothing
+			}
+			if !global {
+				// Without g, sed replaces the first match on each line
+				for _, line := range strings.Split(text, "\n") {
+					if strings.Count(line, old) > 1 {
+						return nil
+					}
+				}
+			}
+			replaceAll = count > 1
+		}
+		calls = append(calls, toolCall{Tool: "Edit", Input: editInput{
+			FilePath:   path,
+			OldString:  old,
+			NewString:  replacement,
+			ReplaceAll: replaceAll,
+		}})
+	}
+	return calls
+}
+
+// parseSubstitution parses a single s/pattern/replacement/[g] command whose
+// pattern and replacement contain no regex or backreference syntax
+func parseSubstitution(script string, extended bool) (string, string, bool, bool) {
+	script = strings.TrimSpace(script)
+	if len(script) < 4 || script[0] != 's' || script[1] == '\\' || script[1] == '\n' {
+		return "", "", false, false
+	}
+	delim := script[1]
+
+	var parts []string
+	var current strings.Builder
+	for i := 2; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\\' && i+1 < len(script):
+			current.WriteByte(c)
+			current.WriteByte(script[i+1])
+			i++
+		case c == delim && len(parts) < 2:
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	if len(parts) != 2 {
+		return "", "", false, false
+	}
+	flags := current.String()
+	if flags != "" && flags != "g" {
+		return "", "", false, false
+	}
+
+	meta := `.[]*^$`
+	if extended {
+		meta += `+?(){}|`
+	}
+	old, ok := literalSed(parts[0], delim, meta, false)
+	if !ok || old == "" || strings.Contains(old, "\n") {
+		return "", "", false, false
+	}
+	replacement, ok := literalSed(parts[1], delim, "&", true)
+	if !ok || strings.ContainsAny(replacement, "$`") || strings.ContainsAny(old, "$`") {
+		return "", "", false, false
+	}
+	return old, replacement, flags == "g", true
+}
+
+// literalSed removes escapes from a sed pattern or replacement, reporting
+// false if it uses any of the meta characters or escape sequences
+func literalSed(s string, delim byte, meta string, replacement bool) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			if strings.IndexByte(meta, c) >= 0 {
+				return "", false
+			}
+			b.WriteByte(c)
+			continue
+		}
+		if i+1 == len(s) {
+			return "", false
+		}
+		i++
+		next := s[i]
+		switch {
+		case next == delim || next == '\\' || next == '/':
+			b.WriteByte(next)
+		case next == 'n':
+			b.WriteByte('\n')
+		case next == 't':
+			b.WriteByte('\t')
+		case replacement && next == '&':
+			b.WriteByte('&')
+		case !replacement && strings.IndexByte(`.[]*^$`, next) >= 0:
+			b.WriteByte(next)
+		default:
+			return "", false // Backreferences, \+, \(, \w and friends
+		}
+	}
+	return b.String(), true
+}
+
+// suggestPath resolves a command's file argument against the directory
+// the command runs in. Paths the shell would expand are rejected.
+func suggestPath(p, cwd string) string {
+	if p == "" || strings.ContainsAny(p, "$`*?") || strings.HasPrefix(p, "/dev/") {
+		return ""
+	}
+	if strings.HasPrefix(p, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		return filepath.Join(homeDir, p[2:])
+	}
+	if filepath.IsAbs(p) {
+		return filepath.Clean(p)
+	}
+	if cwd == "" {
+		return ""
+	}
+	return filepath.Join(cwd, p)
+}
+
+func readForSuggestion(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() || info.Size() > maxSuggestFileSize {
+		return "", fmt.Errorf("%s: not a small regular file", path)
+	}
+	data, err := os.ReadFile(path)
+	return string(data), err
+}
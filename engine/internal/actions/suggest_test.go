@@ -0,0 +1,96 @@
+package actions
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/conditions"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+)
+
+func TestSuggestToolCalls(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	cwd := t.TempDir()
+	files := map[string]string{
+		"log.txt":  "first\nsecond\n",
+		"main.go":  "foo := 1\n",
+		"twice.go": "foo\nfoo\n",
+	}
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(cwd, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	in := func(p string) string { return filepath.Join(cwd, p) }
+	write := func(p, content string) toolCall {
+		return toolCall{Tool: "Write", Input: writeInput{FilePath: p, Content: content}}
+	}
+	edit := func(p, old, replacement string, all bool) toolCall {
+		return toolCall{Tool: "Edit", Input: editInput{FilePath: p, OldString: old, NewString: replacement, ReplaceAll: all}}
+	}
+
+	tests := []struct {
+		command string
+		want    []toolCall
+	}{
+		{"echo hello > notes.txt", []toolCall{write(in("notes.txt"), "hello\n")}},
+		{"echo -n hi > notes.txt", []toolCall{write(in("notes.txt"), "hi")}},
+		{"echo -e 'a\\tb' > notes.txt", []toolCall{write(in("notes.txt"), "a\tb\n")}},
+		{"printf 'a\\nb\\n' > notes.txt", []toolCall{write(in("notes.txt"), "a\nb\n")}},
+		{"printf '100%%\\n' > notes.txt", []toolCall{write(in("notes.txt"), "100%\n")}},
+		{"cat > notes.txt <<'EOF'\nline one\nline two\nEOF", []toolCall{write(in("notes.txt"), "line one\nline two\n")}},
+		{"cat <<< 'here' > notes.txt", []toolCall{write(in("notes.txt"), "here\n")}},
+		{"echo third >> log.txt", []toolCall{edit(in("log.txt"), "second\n", "second\nthird\n", false)}},
+		{"echo new >> missing.txt", []toolCall{write(in("missing.txt"), "new\n")}},
+		{"echo hi | tee a.txt b.txt", []toolCall{write(in("a.txt"), "hi\n"), write(in("b.txt"), "hi\n")}},
+		{"echo third | tee -a log.txt", []toolCall{edit(in("log.txt"), "second\n", "second\nthird\n", false)}},
+		{"sed -i 's/foo/bar/' main.go", []toolCall{edit(in("main.go"), "foo", "bar", false)}},
+		{"sed -i.bak -e 's|foo|bar|g' twice.go", []toolCall{edit(in("twice.go"), "foo", "bar", true)}},
+		{"echo hi > /tmp/abs.txt", []toolCall{write("/tmp/abs.txt", "hi\n")}},
+		{"echo hi > ~/notes.txt", []toolCall{write(filepath.Join(home, "notes.txt"), "hi\n")}},
+
+		// The directory a cd leaves the shell in
+		{"cd sub && echo hello > notes.txt", []toolCall{write(in("sub/notes.txt"), "hello\n")}},
+		{"cd sub && sed -i 's/foo/bar/' main.go", []toolCall{edit(in("sub/main.go"), "foo", "bar", false)}},
+		{"cd /srv && echo hi | tee out.txt", []toolCall{write("/srv/out.txt", "hi\n")}},
+		{"(cd sub && true); echo hi > notes.txt", []toolCall{write(in("notes.txt"), "hi\n")}},
+		{"cd sub; echo hello > notes.txt", nil},
+		{"cd \"$(mktemp -d)\" && echo hello > notes.txt", nil},
+		{"cd - && echo hello > notes.txt", nil},
+
+		// Anything the shell would expand
+		{"echo $HOME > notes.txt", nil},
+		{"echo `date` > notes.txt", nil},
+		{"printf '%s\\n' x > notes.txt", nil},
+		{"echo hi > $OUT", nil},
+		{"echo hi > *.txt", nil},
+		{"sed -i 's/fo*/bar/' main.go", nil},
+		{"sed -i 's/\\(foo\\)/\\1bar/' main.go", nil},
+		{"sed -i 's/absent/bar/' main.go", nil},
+		{"sed -n -i 's/foo/bar/p' main.go", nil},
+		{"echo hi 2> err.txt", nil},
+		{"echo hi > /dev/null", nil},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(map[string]any{
+			"hook_event_name": "PreToolUse",
+			"tool_name":       "Bash",
+			"tool_input":      map[string]any{"command": tt.command},
+			"cwd":             cwd,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		event, err := conditions.NewEvent(data, map[string]string{"HOME": home}, &config.Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := suggestToolCalls(event); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q suggests %+v, want %+v", tt.command, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,387 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/conditions"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+)
+
+// templateFuncs are the filters available in message templates
+var templateFuncs = template.FuncMap{
+	"truncate": truncateFilter,
+	"basename": basenameFilter,
+	"quote":    quoteFilter,
+	"json":     jsonFilter,
+	"default":  defaultFilter,
+}
+
+// Tool input fields of Claude Code's built-in tools. Templates may use them
+// unqualified ({{.file_path}}), so validation accepts them.
+var toolInputFields = []string{
+	"file_path", "content", "old_string", "new_string", "replace_all", "edits",
+	"command", "description", "timeout", "run_in_background", "pattern", "path",
+	"glob", "notebook_path", "new_source", "cell_id", "url", "query",
+	"subagent_type", "todos",
+}
+
+// legacyPlaceholder matches the pre-text/template syntax {{name}} and
+// {{name.sub}}, which lacks the leading dot
+var legacyPlaceholder = regexp.MustCompile(`\{\{(-?\s*)([A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z0-9_]+)*)(\s*-?)\}\}`)
+
+var templateCache sync.Map // text -> *template.Template
+
+// renderTemplate renders a message template against the event. Values are
+// inserted once and never expanded again, so tool input containing {{...}}
+// stays literal. String params are themselves templates written in config
+// and are rendered one level deep before use. Secrets in the result are
+// redacted, since messages end up in the transcript.
+func renderTemplate(text string, event *conditions.HookEvent, params map[string]any, cfg *config.Config) string {
+	return Redact(render(text, event, params, true), cfg)
+}
+
+func render(text string, event *conditions.HookEvent, params map[string]any, expandParams bool) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	tmpl, err := parseTemplate(text)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Template error: %v\n", err)
+		return text
+	}
+	refs := templateRefs(tmpl)
+
+	data := make(map[string]any)
+	// Tool input fields are also available unqualified
+	for k, v := range event.ToolInput {
+		data[k] = v
+	}
+	for _, chain := range refs {
+		if v := event.Field(chain[0]); v != nil {
+			data[chain[0]] = v
+		}
+	}
+	for k, v := range event.Captures {
+		data[k] = v
+	}
+	for k, v := range params {
+		if s, ok := v.(string); ok && expandParams && referencesRoot(refs, k) {
+			v = render(s, event, params, false)
+		}
+		data[k] = v
+	}
+
+	// Missing fields render empty rather than as "<no value>"
+	for _, chain := range refs {
+		prefill(data, chain)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		fmt.Fprintf(os.Stderr, "Template error: %v\n", err)
+		return text
+	}
+	return b.String()
+}
+
+// parseTemplate compiles a template, converting legacy placeholders first.
+// Compiled templates are cached, since the daemon renders the same
+// messages repeatedly.
+func parseTemplate(text string) (*template.Template, error) {
+	if cached, ok := templateCache.Load(text); ok {
+		return cached.(*template.Template), nil
+	}
+	tmpl, err := template.New("message").Funcs(templateFuncs).Parse(convertLegacy(text))
+	if err != nil {
+		return nil, err
+	}
+	templateCache.Store(text, tmpl)
+	return tmpl, nil
+}
+
+// convertLegacy rewrites {{name}} to {{.name}}, leaving keywords and
+// function calls alone
+func convertLegacy(text string) string {
+	return legacyPlaceholder.ReplaceAllStringFunc(text, func(match string) string {
+		parts := legacyPlaceholder.FindStringSubmatch(match)
+		root, _, _ := strings.Cut(parts[2], ".")
+		switch root {
+		case "end", "else", "break", "continue", "nil", "true", "false":
+			return match
+		}
+		if _, isFunc := templateFuncs[root]; isFunc {
+			return match
+		}
+		return "{{" + parts[1] + "." + parts[2] + parts[3] + "}}"
+	})
+}
+
+// templateRefs collects the field chains a template reads from its root
+// data. Inside range and with bodies, plain fields are relative to another
+// value, so only $-rooted variables are collected there.
+func templateRefs(tmpl *template.Template) [][]string {
+	var refs [][]string
+	var walk func(node parse.Node, relative bool)
+	walk = func(node parse.Node, relative bool) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n != nil {
+				for _, child := range n.Nodes {
+					walk(child, relative)
+				}
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe, relative)
+		case *parse.IfNode:
+			walk(n.Pipe, relative)
+			walk(n.List, relative)
+			walk(n.ElseList, relative)
+		case *parse.RangeNode:
+			walk(n.Pipe, relative)
+			walk(n.List, true)
+			walk(n.ElseList, relative)
+		case *parse.WithNode:
+			walk(n.Pipe, relative)
+			walk(n.List, true)
+			walk(n.ElseList, relative)
+		case *parse.TemplateNode:
+			walk(n.Pipe, relative)
+		case *parse.PipeNode:
+			if n != nil {
+				for _, cmd := range n.Cmds {
+					walk(cmd, relative)
+				}
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg, relative)
+			}
+		case *parse.FieldNode:
+			if !relative {
+				refs = append(refs, n.Ident)
+			}
+		case *parse.VariableNode:
+			if len(n.Ident) > 1 && n.Ident[0] == "$" {
+				refs = append(refs, n.Ident[1:])
+			}
+		case *parse.ChainNode:
+			walk(n.Node, relative)
+		}
+	}
+	if tmpl.Tree != nil {
+		walk(tmpl.Tree.Root, false)
+	}
+	return refs
+}
+
+func referencesRoot(refs [][]string, root string) bool {
+	for _, chain := range refs {
+		if chain[0] == root {
+			return true
+		}
+	}
+	return false
+}
+
+// prefill makes every step of a field chain present in data, copying maps
+// on the way so the event's own values are never modified
+func prefill(data map[string]any, chain []string) {
+	current := data
+	for i, key := range chain {
+		value, exists := current[key]
+		if i == len(chain)-1 {
+			if !exists || value == nil {
+				current[key] = ""
+			}
+			return
+		}
+		var next map[string]any
+		switch v := value.(type) {
+		case nil:
+			next = make(map[string]any)
+		case map[string]any:
+			next = copyParams(v)
+		default:
+			return // Not a map; let the template report the access
+		}
+		current[key] = next
+		current = next
+	}
+}
+
+// TemplateIssues reports parse errors and undefined variables in the
+// templates of an action, following refs, chains and conditionals the way
+// Execute does
+func TemplateIssues(action *config.Action, cfg *config.Config) []string {
+	known := make(map[string]bool)
+	for _, name := range conditions.FieldRoots() {
+		known[name] = true
+	}
+	for _, name := range conditions.BuiltinCaptures() {
+		known[name] = true
+	}
+	for _, name := range toolInputFields {
+		known[name] = true
+	}
+
+	var issues []string
+	seen := make(map[string]bool)
+	var check func(action *config.Action, params map[string]any, depth int)
+	check = func(action *config.Action, params map[string]any, depth int) {
+		if action == nil || depth > 10 {
+			return
+		}
+		if action.Ref != "" {
+			ref, exists := cfg.Actions[action.Ref]
+			if !exists {
+				return
+			}
+			merged := copyParams(ref.Params)
+			for k, v := range params {
+				merged[k] = v
+			}
+			for k, v := range action.Params {
+				merged[k] = v
+			}
+			ref.Params = merged
+			if action.Suggest != nil {
+				ref.Suggest = action.Suggest
+			}
+			check(&ref, nil, depth+1)
+			return
+		}
+
+		merged := copyParams(action.Params)
+		for k, v := range params {
+			if _, exists := merged[k]; !exists {
+				merged[k] = v
+			}
+		}
+
+		texts := map[string]string{"message": action.Message}
+		for k, v := range merged {
+			if s, ok := v.(string); ok {
+				texts["params."+k] = s
+			}
+		}
+		for k, v := range action.Set {
+			if s, ok := v.(string); ok {
+				texts["set."+k] = s
+			}
+		}
+		if action.Suggest != nil {
+			texts["suggest.command"] = action.Suggest.Command
+		}
+		for _, where := range sortedKeys(texts) {
+			text := texts[where]
+			if !strings.Contains(text, "{{") {
+				continue
+			}
+			tmpl, err := parseTemplate(text)
+			if err != nil {
+				issues = appendOnce(issues, seen, fmt.Sprintf("%s: %v", where, err))
+				continue
+			}
+			for _, chain := range templateRefs(tmpl) {
+				if _, isParam := merged[chain[0]]; !known[chain[0]] && !isParam {
+					issues = appendOnce(issues, seen, fmt.Sprintf("%s: undefined variable .%s", where, strings.Join(chain, ".")))
+				}
+			}
+		}
+
+		for i := range action.Actions {
+			check(&action.Actions[i], merged, depth+1)
+		}
+		check(action.Then, merged, depth+1)
+		check(action.Else, merged, depth+1)
+	}
+	check(action, nil, 0)
+	return issues
+}
+
+func appendOnce(list []string, seen map[string]bool, item string) []string {
+	if seen[item] {
+		return list
+	}
+	seen[item] = true
+	return append(list, item)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toText formats a template value, rendering nil as empty
+func toText(v any) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// truncateFilter shortens a value to n characters: {{.command | truncate 80}}
+func truncateFilter(n int, v any) string {
+	text := []rune(toText(v))
+	if n < 1 || len(text) <= n {
+		return string(text)
+	}
+	return string(text[:n-1]) + "…"
+}
+
+// basenameFilter returns the last element of a path: {{.file_path | basename}}
+func basenameFilter(v any) string {
+	if text := toText(v); text != "" {
+		return filepath.Base(text)
+	}
+	return ""
+}
+
+// quoteFilter wraps a value in double quotes, escaping as Go does
+func quoteFilter(v any) string {
+	return strconv.Quote(toText(v))
+}
+
+// jsonFilter encodes a value as JSON: {{.tool_input | json}}
+func jsonFilter(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// defaultFilter substitutes a fallback for empty values:
+// {{.session.ticket | default "none"}}
+func defaultFilter(fallback, v any) any {
+	if v == nil {
+		return fallback
+	}
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.String, reflect.Map, reflect.Slice, reflect.Array:
+		if value.Len() == 0 {
+			return fallback
+		}
+	default:
+		if value.IsZero() {
+			return fallback
+		}
+	}
+	return v
+}
@@ -0,0 +1,86 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/conditions"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/webhook"
+)
+
+// DefaultWebhookTimeout bounds a webhook action when it sets no timeout
+const DefaultWebhookTimeout = time.Second
+
+var webhookClient = &http.Client{}
+
+// executeWebhook POSTs the event to a URL within the action's time budget.
+// Deliveries that fail are spooled for `hookctl spool flush` rather than
+// delaying the decision. An empty URL (e.g. an unset url_env variable)
+// disables the action.
+func executeWebhook(action *config.Action, event *conditions.HookEvent, cfg *config.Config) *Response {
+	url := webhookURL(action, event)
+	if url == "" {
+		return nil
+	}
+
+	budget := DefaultWebhookTimeout
+	if action.Timeout > 0 {
+		budget = time.Duration(action.Timeout) * time.Millisecond
+	}
+
+	body := webhookBody(event, cfg)
+	if action.Body != "" {
+		body = renderTemplate(action.Body, event, action.Params, cfg)
+		if !webhook.ValidBody(body) {
+			fmt.Fprintf(os.Stderr, "Webhook %s: body is not valid JSON\n", url)
+		}
+	}
+
+	delivery := &webhook.Delivery{
+		URL:     url,
+		Headers: action.Headers,
+		Body:    body,
+		Created: time.Now().UTC(),
+	}
+
+	ctx, cancel := context.WithTimeout(event.Context(), budget)
+	defer cancel()
+	if err := webhook.Send(ctx, webhookClient, delivery, action.Retries); err != nil {
+		delivery.LastError = err.Error()
+		spool := webhook.Spool{Dir: webhook.DefaultSpoolDir()}
+		if err := spool.Save(delivery); err != nil {
+			fmt.Fprintf(os.Stderr, "Webhook %s: failed to spool: %v\n", url, err)
+		}
+	}
+	return nil // Non-terminal action
+}
+
+// webhookURL returns where a webhook action posts this event: the url_env
+// variable of the event's environment when one is named, else url
+func webhookURL(action *config.Action, event *conditions.HookEvent) string {
+	if action.URLEnv != "" {
+		return event.Env[action.URLEnv]
+	}
+	return action.URL
+}
+
+// webhookBody is the default payload: the event with secrets redacted
+func webhookBody(event *conditions.HookEvent, cfg *config.Config) string {
+	payload := map[string]any{
+		"ts":         time.Now().UTC().Format(time.RFC3339Nano),
+		"session_id": event.SessionID,
+		"cwd":        event.Cwd,
+		"event":      event.HookType,
+		"tool_name":  event.ToolName,
+		"tool_input": RedactValue(event.ToolInput, cfg),
+		"profile":    event.Raw["profile"],
+		"rule_id":    event.Raw["rule_id"],
+	}
+	data, _ := json.Marshal(payload)
+	return string(data)
+}
@@ -0,0 +1,150 @@
+package actions
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/conditions"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/webhook"
+)
+
+func testEvent(t *testing.T, cfg *config.Config) *conditions.HookEvent {
+	t.Helper()
+	data := `{"hook_event_name":"PreToolUse","session_id":"s1","tool_name":"Bash","tool_input":{"command":"ls"}}`
+	event, err := conditions.NewEvent([]byte(data), map[string]string{}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestExecuteWebhookDelivers(t *testing.T) {
+	var payload map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &payload)
+	}))
+	defer server.Close()
+	spoolDir := t.TempDir()
+	t.Setenv("WORKFLOW_GUARD_SPOOL_DIR", spoolDir)
+
+	cfg := &config.Config{}
+	action := &config.Action{Type: "webhook", URL: server.URL}
+	if resp, _ := Execute(action, testEvent(t, cfg), cfg, &Response{}); resp != nil {
+		t.Fatalf("webhook returned a terminal response: %+v", resp)
+	}
+	if payload["tool_name"] != "Bash" || payload["session_id"] != "s1" {
+		t.Errorf("payload = %v, want the event", payload)
+	}
+	assertSpooled(t, spoolDir, 0)
+}
+
+// The daemon runs actions for many hooks: the URL comes from each event's
+// environment, never from the process that loaded the config
+func TestExecuteWebhookURLFromEventEnv(t *testing.T) {
+	hits := map[string]int{}
+	handler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits[name]++ })
+	}
+	hook := httptest.NewServer(handler("hook"))
+	defer hook.Close()
+	daemon := httptest.NewServer(handler("daemon"))
+	defer daemon.Close()
+	spoolDir := t.TempDir()
+	t.Setenv("WORKFLOW_GUARD_SPOOL_DIR", spoolDir)
+	t.Setenv("WORKFLOW_GUARD_WEBHOOK_URL", daemon.URL)
+
+	cfg := &config.Config{}
+	action := &config.Action{Type: "webhook", URLEnv: "WORKFLOW_GUARD_WEBHOOK_URL"}
+	data := []byte(`{"hook_event_name":"PreToolUse","session_id":"s1","tool_name":"Bash"}`)
+	for _, env := range []map[string]string{
+		{"WORKFLOW_GUARD_WEBHOOK_URL": hook.URL},
+		{}, // Unset in this hook: disabled
+	} {
+		event, err := conditions.NewEvent(data, env, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		Execute(action, event, cfg, &Response{})
+	}
+	if hits["hook"] != 1 || hits["daemon"] != 0 {
+		t.Errorf("deliveries = %v, want one to the hook's URL", hits)
+	}
+	assertSpooled(t, spoolDir, 0)
+}
+
+func TestExecuteWebhookSpoolsFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	spoolDir := t.TempDir()
+	t.Setenv("WORKFLOW_GUARD_SPOOL_DIR", spoolDir)
+
+	cfg := &config.Config{}
+	action := &config.Action{Type: "webhook", URL: server.URL, Retries: 1, Timeout: 500}
+	Execute(action, testEvent(t, cfg), cfg, &Response{})
+
+	files := assertSpooled(t, spoolDir, 1)
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d webhook.Delivery
+	if err := json.Unmarshal(data, &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.URL != server.URL || d.Attempts != 2 || d.LastError == "" {
+		t.Errorf("spooled delivery = %+v, want %s after 2 attempts", d, server.URL)
+	}
+}
+
+func TestExecuteDryRunSkipsSideEffects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("dry run posted the webhook")
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	t.Setenv("WORKFLOW_GUARD_SPOOL_DIR", dir)
+	logFile := filepath.Join(dir, "tool-use.jsonl")
+
+	cfg := &config.Config{}
+	event := testEvent(t, cfg)
+	event.DryRun = true
+	out := &Response{}
+	action := &config.Action{Type: "chain", Actions: []config.Action{
+		{Type: "log", Params: map[string]any{"log_file": logFile}},
+		{Type: "webhook", URL: server.URL},
+		{Type: "notify", Message: "checked"},
+	}}
+	Execute(action, event, cfg, out)
+
+	want := []string{"log " + logFile, "webhook " + server.URL}
+	if len(out.Skipped) != 2 || out.Skipped[0] != want[0] || out.Skipped[1] != want[1] {
+		t.Errorf("skipped = %v, want %v", out.Skipped, want)
+	}
+	if len(out.SystemMessages) != 1 {
+		t.Errorf("system messages = %v, want the notification", out.SystemMessages)
+	}
+	if _, err := os.Stat(logFile); !os.IsNotExist(err) {
+		t.Errorf("dry run wrote %s", logFile)
+	}
+}
+
+func assertSpooled(t *testing.T, dir string, want int) []string {
+	t.Helper()
+	files, err := webhook.Spool{Dir: dir}.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != want {
+		t.Fatalf("spooled %d deliveries, want %d", len(files), want)
+	}
+	return files
+}
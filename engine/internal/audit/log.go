@@ -0,0 +1,223 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+)
+
+// Defaults used when settings.yaml leaves audit options unset
+const (
+	DefaultMaxSizeMB = 10
+	DefaultMaxFiles  = 5
+)
+
+// Redacted replaces the values of redacted fields
+const Redacted = "[REDACTED]"
+
+// Record is one dispatch outcome in the decision log
+type Record struct {
+	Timestamp time.Time      `json:"ts"`
+	SessionID string         `json:"session_id,omitempty"`
+	Cwd       string         `json:"cwd,omitempty"`
+	Event     string         `json:"event"`
+	ToolName  string         `json:"tool_name,omitempty"`
+	ToolInput map[string]any `json:"tool_input,omitempty"`
+	Profile   string         `json:"profile,omitempty"`
+	Rules     []string       `json:"rules"`              // Every rule whose conditions matched, in order
+	Decision  string         `json:"decision,omitempty"` // allow, deny, ask or "" (no decision)
+	Message   string         `json:"message,omitempty"`  // Reason shown with the decision
+	Halt      bool           `json:"halt,omitempty"`     // Claude was stopped
+	Error     string         `json:"error,omitempty"`    // Why the event, or a rule, could not be evaluated
+	LatencyMS float64        `json:"latency_ms"`         // Rule evaluation time
+}
+
+// Logger appends records to a JSONL file, rotating it by size. Writes are
+// serialized with a file lock, since the daemon and in-process dispatchers
+// may log at the same time.
+type Logger struct {
+	Path     string
+	MaxSize  int64         // Bytes; 0 never rotates
+	MaxFiles int           // Rotated files to keep
+	MaxAge   time.Duration // Files last written longer ago are deleted; 0 keeps them
+	Redact   []string
+}
+
+// DefaultPath returns the decision log location.
+// WORKFLOW_GUARD_AUDIT_LOG overrides ~/.claude/workflow-guard/decisions.jsonl.
+func DefaultPath() string {
+	if path := os.Getenv("WORKFLOW_GUARD_AUDIT_LOG"); path != "" {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "workflow-guard", "decisions.jsonl")
+	}
+	return filepath.Join(homeDir, ".claude", "workflow-guard", "decisions.jsonl")
+}
+
+// NewLogger builds a logger from settings. It returns nil when the decision
+// log is disabled.
+func NewLogger(settings config.AuditSettings) *Logger {
+	if settings.Disabled {
+		return nil
+	}
+	logger := &Logger{
+		Path:     settings.Path,
+		MaxSize:  int64(settings.MaxSizeMB) << 20,
+		MaxFiles: settings.MaxFiles,
+		MaxAge:   time.Duration(settings.MaxAgeDays) * 24 * time.Hour,
+		Redact:   settings.RedactFields,
+	}
+	if logger.Path == "" {
+		logger.Path = DefaultPath()
+	}
+	logger.Path = expandHome(logger.Path)
+	if logger.MaxSize == 0 {
+		logger.MaxSize = DefaultMaxSizeMB << 20
+	}
+	if logger.MaxFiles == 0 {
+		logger.MaxFiles = DefaultMaxFiles
+	}
+	return logger
+}
+
+// Write appends a record, redacting configured fields first
+func (l *Logger) Write(record *Record) error {
+	if l == nil {
+		return nil
+	}
+	line, err := l.encode(record)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0700); err != nil {
+		return err
+	}
+	lock, err := os.OpenFile(l.Path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(lock.Fd()), syscall.LOCK_UN)
+
+	l.prune()
+	if info, err := os.Stat(l.Path); err == nil && l.MaxSize > 0 && info.Size()+int64(len(line)) > l.MaxSize {
+		if err := l.rotate(); err != nil {
+			return fmt.Errorf("rotate %s: %w", l.Path, err)
+		}
+	}
+
+	f, err := os.OpenFile(l.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(line)
+	return err
+}
+
+// encode marshals a record as one JSON line with redacted fields replaced
+func (l *Logger) encode(record *Record) ([]byte, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	if len(l.Redact) > 0 {
+		var generic map[string]any
+		if err := json.Unmarshal(data, &generic); err != nil {
+			return nil, err
+		}
+		for _, field := range l.Redact {
+			redact(generic, strings.Split(field, "."))
+		}
+		if data, err = json.Marshal(generic); err != nil {
+			return nil, err
+		}
+	}
+	return append(data, '\n'), nil
+}
+
+// rotate shifts decisions.jsonl to .1, .1 to .2 and so on, dropping files
+// beyond MaxFiles
+func (l *Logger) rotate() error {
+	os.Remove(fmt.Sprintf("%s.%d", l.Path, l.MaxFiles))
+	for i := l.MaxFiles - 1; i >= 1; i-- {
+		from := fmt.Sprintf("%s.%d", l.Path, i)
+		if _, err := os.Stat(from); err == nil {
+			os.Rename(from, fmt.Sprintf("%s.%d", l.Path, i+1))
+		}
+	}
+	return os.Rename(l.Path, l.Path+".1")
+}
+
+// prune deletes the log and rotated files last written over MaxAge ago. It
+// runs on every write, not only on rotation, so a log too quiet to reach
+// MaxSize still ages out.
+func (l *Logger) prune() {
+	if l.MaxAge <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-l.MaxAge)
+	paths := []string{l.Path}
+	for i := 1; i <= l.MaxFiles; i++ {
+		paths = append(paths, fmt.Sprintf("%s.%d", l.Path, i))
+	}
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && info.ModTime().Before(cutoff) {
+			os.Remove(path)
+		}
+	}
+}
+
+// redact replaces the value at a dotted path. A "*" step matches every key
+// of a map or element of a list.
+func redact(value any, path []string) {
+	if len(path) == 0 {
+		return
+	}
+	step, rest := path[0], path[1:]
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if step != "*" && key != step {
+				continue
+			}
+			if len(rest) == 0 {
+				v[key] = Redacted
+			} else {
+				redact(child, rest)
+			}
+		}
+	case []any:
+		if step != "*" {
+			return
+		}
+		for i, child := range v {
+			if len(rest) == 0 {
+				v[i] = Redacted
+			} else {
+				redact(child, rest)
+			}
+		}
+	}
+}
+
+func expandHome(p string) string {
+	if strings.HasPrefix(p, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(homeDir, p[2:])
+		}
+	}
+	return p
+}
@@ -0,0 +1,208 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+)
+
+func testLogger(t *testing.T) *Logger {
+	t.Helper()
+	return &Logger{
+		Path:     filepath.Join(t.TempDir(), "decisions.jsonl"),
+		MaxFiles: DefaultMaxFiles,
+	}
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// age backdates a file's modification time
+func age(t *testing.T, path string, d time.Duration) {
+	t.Helper()
+	when := time.Now().Add(-d)
+	if err := os.Chtimes(path, when, when); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	t.Setenv("HOME", "/home/tester")
+	t.Setenv("WORKFLOW_GUARD_AUDIT_LOG", "")
+
+	if NewLogger(config.AuditSettings{Disabled: true}) != nil {
+		t.Error("disabled settings built a logger")
+	}
+
+	logger := NewLogger(config.AuditSettings{})
+	if want := "/home/tester/.claude/workflow-guard/decisions.jsonl"; logger.Path != want {
+		t.Errorf("Path = %q, want %q", logger.Path, want)
+	}
+	if logger.MaxSize != DefaultMaxSizeMB<<20 || logger.MaxFiles != DefaultMaxFiles || logger.MaxAge != 0 {
+		t.Errorf("defaults = %d bytes, %d files, %v", logger.MaxSize, logger.MaxFiles, logger.MaxAge)
+	}
+
+	logger = NewLogger(config.AuditSettings{Path: "~/audit.jsonl", MaxSizeMB: 1, MaxFiles: 2, MaxAgeDays: 3})
+	if logger.Path != "/home/tester/audit.jsonl" || logger.MaxSize != 1<<20 || logger.MaxFiles != 2 || logger.MaxAge != 72*time.Hour {
+		t.Errorf("logger = %+v", logger)
+	}
+}
+
+func TestWriteRedacts(t *testing.T) {
+	logger := testLogger(t)
+	logger.Redact = []string{"tool_input.content", "tool_input.edits.*.new_string", "message"}
+
+	err := logger.Write(&Record{
+		Event:    "PreToolUse",
+		ToolName: "MultiEdit",
+		ToolInput: map[string]any{
+			"file_path": "/repo/main.go",
+			"content":   "secret",
+			"edits": []any{
+				map[string]any{"old_string": "a", "new_string": "b"},
+				map[string]any{"old_string": "c", "new_string": "d"},
+			},
+		},
+		Rules: []string{"confirm-code-edits"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := readLines(t, logger.Path)
+	if len(lines) != 1 {
+		t.Fatalf("lines = %q", lines)
+	}
+	var got map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatal(err)
+	}
+	input := got["tool_input"].(map[string]any)
+	if input["content"] != Redacted || input["file_path"] != "/repo/main.go" {
+		t.Errorf("tool_input = %v", input)
+	}
+	for _, edit := range input["edits"].([]any) {
+		edit := edit.(map[string]any)
+		if edit["new_string"] != Redacted || edit["old_string"] == Redacted {
+			t.Errorf("edit = %v", edit)
+		}
+	}
+	// Redacting a field the record leaves out does not add it
+	if _, ok := got["message"]; ok {
+		t.Errorf("message = %v, want it left out", got["message"])
+	}
+}
+
+func TestWriteRotates(t *testing.T) {
+	logger := testLogger(t)
+	logger.MaxFiles = 2
+	record := &Record{Event: "PreToolUse", ToolName: "0", Rules: []string{}}
+	line, err := logger.encode(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two records fit, the third rotates
+	logger.MaxSize = int64(2 * len(line))
+
+	for i := 0; i < 7; i++ {
+		record.ToolName = fmt.Sprint(i)
+		if err := logger.Write(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for path, want := range map[string]int{
+		logger.Path:        1, // Record 6
+		logger.Path + ".1": 2, // 4 and 5
+		logger.Path + ".2": 2, // 2 and 3
+	} {
+		if lines := readLines(t, path); len(lines) != want {
+			t.Errorf("%s has %d lines, want %d", filepath.Base(path), len(lines), want)
+		}
+	}
+	if exists(logger.Path + ".3") {
+		t.Error("kept more than MaxFiles rotated files")
+	}
+}
+
+func TestWritePrunesByAge(t *testing.T) {
+	tests := []struct {
+		name   string
+		maxAge time.Duration
+		kept   []string // Suffixes left after the write
+	}{
+		{"no max age", 0, []string{"", ".1", ".2", ".3"}},
+		// No rotation happens, the stale files go anyway
+		{"stale files", 24 * time.Hour, []string{"", ".1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := testLogger(t)
+			logger.MaxAge = tt.maxAge
+			for suffix, d := range map[string]time.Duration{
+				"":   48 * time.Hour,
+				".1": time.Hour,
+				".2": 48 * time.Hour,
+				".3": 72 * time.Hour,
+			} {
+				if err := os.WriteFile(logger.Path+suffix, []byte("{}\n"), 0600); err != nil {
+					t.Fatal(err)
+				}
+				age(t, logger.Path+suffix, d)
+			}
+
+			if err := logger.Write(&Record{Event: "Stop"}); err != nil {
+				t.Fatal(err)
+			}
+
+			var kept []string
+			for _, suffix := range []string{"", ".1", ".2", ".3"} {
+				if exists(logger.Path + suffix) {
+					kept = append(kept, suffix)
+				}
+			}
+			if fmt.Sprint(kept) != fmt.Sprint(tt.kept) {
+				t.Errorf("kept %q, want %q", kept, tt.kept)
+			}
+		})
+	}
+
+	// A stale live log is replaced by the new record alone
+	logger := testLogger(t)
+	logger.MaxAge = 24 * time.Hour
+	if err := os.WriteFile(logger.Path, []byte("{\"event\":\"old\"}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	age(t, logger.Path, 48*time.Hour)
+	if err := logger.Write(&Record{Event: "Stop"}); err != nil {
+		t.Fatal(err)
+	}
+	if lines := readLines(t, logger.Path); len(lines) != 1 || !strings.Contains(lines[0], `"event":"Stop"`) {
+		t.Errorf("lines = %q", lines)
+	}
+}
+
+func TestNilLogger(t *testing.T) {
+	var logger *Logger
+	if err := logger.Write(&Record{Event: "Stop"}); err != nil {
+		t.Errorf("Write on a disabled logger = %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+package conditions
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/cycle"
+)
+
+// agentFields names the agent an event runs in as agent.*: found, name,
+// known (listed under agents.roles in settings.yaml) and cycle. The agent
+// is the last agents.markers match in the session transcript, which falls
+// back to CLAUDE_TRANSCRIPT_FILE when the hook gets /dev/null. Without a
+// match only found (false) is set.
+func agentFields(e *HookEvent) (any, error) {
+	fields := map[string]any{"found": false}
+	name, err := transcriptAgent(e)
+	if err != nil || name == "" {
+		return fields, err
+	}
+	role, known := e.agents.Roles[name]
+	fields["found"] = true
+	fields["name"] = name
+	fields["known"] = known
+	fields["cycle"] = role.Cycle
+	return fields, nil
+}
+
+// transcriptAgent returns the agent named by the last marker match in the
+// transcript, "" when there is none
+func transcriptAgent(e *HookEvent) (string, error) {
+	path := e.TranscriptPath
+	if path == "" || path == os.DevNull {
+		path = e.Env["CLAUDE_TRANSCRIPT_FILE"]
+	}
+	if path == "" || len(e.agents.Markers) == 0 {
+		return "", nil
+	}
+	markers, err := compileMarkers(e.agents.Markers)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(expandHome(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", &EvalError{Kind: ErrTranscript, Err: err}
+	}
+	defer f.Close()
+
+	name := ""
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if found := lastMarker(line, markers); found != "" {
+				name = found
+			}
+		}
+		if err == io.EOF {
+			return name, nil
+		}
+		if err != nil {
+			return "", &EvalError{Kind: ErrTranscript, Err: err}
+		}
+	}
+}
+
+// TaskAgent returns the agent a Task call dispatches: the one its prompt
+// names with an agents.markers match ("You are working as the
+// code-developer agent"), else its subagent_type without a plugin
+// namespace. The agent's own tool calls find the same marker in their
+// transcript, so agent.name, the description check and the quality cycle
+// all agree on the name.
+func (e *HookEvent) TaskAgent() (string, error) {
+	prompt, _ := e.ToolInput["prompt"].(string)
+	if prompt != "" && len(e.agents.Markers) > 0 {
+		markers, err := compileMarkers(e.agents.Markers)
+		if err != nil {
+			return "", err
+		}
+		if name := lastMarker([]byte(prompt), markers); name != "" {
+			return name, nil
+		}
+	}
+	subagent, _ := e.ToolInput["subagent_type"].(string)
+	return cycle.AgentName(subagent), nil
+}
+
+// compileMarkers compiles agents.markers; each needs a capture group for
+// the agent name
+func compileMarkers(patterns []string) ([]*regexp.Regexp, error) {
+	markers := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err == nil && re.NumSubexp() == 0 {
+			err = errors.New("no capture group for the agent name")
+		}
+		if err != nil {
+			return nil, &EvalError{Kind: ErrInvalidPattern, Condition: "agents.markers", Err: fmt.Errorf("%q: %w", pattern, err)}
+		}
+		markers = append(markers, re)
+	}
+	return markers, nil
+}
+
+// CheckAgentMarkers reports the first agents.markers entry that doesn't
+// compile or has no capture group
+func CheckAgentMarkers(patterns []string) error {
+	_, err := compileMarkers(patterns)
+	return err
+}
+
+// lastMarker returns the agent name of the rightmost marker match in line
+func lastMarker(line []byte, markers []*regexp.Regexp) string {
+	name, at := "", -1
+	for _, re := range markers {
+		for _, m := range re.FindAllSubmatchIndex(line, -1) {
+			if m[0] > at && m[2] >= 0 {
+				name, at = string(line[m[2]:m[3]]), m[0]
+			}
+		}
+	}
+	return name
+}
+
+// evaluateAgentContext matches a tool call the active agent may not make,
+// as declared under agents in settings.yaml: any call outside an agent, a
+// call by an agent missing from agents.roles, a tool missing from the
+// role's tools, or a file modification outside the role's paths. Files
+// matching agents.exempt (tickets, handoffs) are always allowed. It
+// records agent_name, agent_file and agent_reason.
+func evaluateAgentContext(cond *config.Condition, event *HookEvent, cfg *config.Config) (bool, error) {
+	agents := cfg.Settings.Agents
+	file := toolFilePath(event)
+	if file != "" && matchesAnyGlob(agents.Exempt, resolvePath(file, event)) {
+		return false, nil
+	}
+
+	value, err := event.FieldErr("agent")
+	if err != nil {
+		return false, err
+	}
+	fields, _ := value.(map[string]any)
+	name, _ := fields["name"].(string)
+
+	reason := ""
+	role, known := agents.Roles[name]
+	switch {
+	case name == "":
+		reason = fmt.Sprintf("%s needs an agent context and none is active", event.ToolName)
+	case !known:
+		reason = fmt.Sprintf("%s is not a known agent (agents.roles in settings.yaml)", name)
+	case len(role.Tools) > 0 && !containsAny(role.Tools, event.ToolName):
+		reason = fmt.Sprintf("%s may not use %s (allowed: %s)", name, event.ToolName, strings.Join(role.Tools, ", "))
+	case len(role.Paths) > 0 && file != "" && isFileWriteTool(event.ToolName) &&
+		!matchesAnyGlob(role.Paths, resolvePath(file, event)):
+		reason = fmt.Sprintf("%s may only modify %s", name, strings.Join(role.Paths, ", "))
+	default:
+		return false, nil
+	}
+	event.capture("agent_name", name)
+	event.capture("agent_file", file)
+	event.capture("agent_reason", reason)
+	return true, nil
+}
+
+// toolFilePath returns the file or directory a tool call works on
+func toolFilePath(event *HookEvent) string {
+	for _, key := range []string{"file_path", "notebook_path", "path"} {
+		if p, ok := event.ToolInput[key].(string); ok && p != "" {
+			return p
+		}
+	}
+	return ""
+}
+
+// matchesAnyGlob matches a path against globs. Globs without a slash match
+// the base name; others match whole trailing path segments, with **
+// spanning directories (tickets/**/TICKET-*.md).
+func matchesAnyGlob(globs []string, p string) bool {
+	p = strings.ReplaceAll(p, string(os.PathSeparator), "/")
+	for _, glob := range globs {
+		if re, err := globRegexp(glob); err == nil && re.MatchString(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func globRegexp(glob string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	if strings.HasPrefix(glob, "/") {
+		b.WriteString("^")
+	} else {
+		b.WriteString("(^|/)")
+	}
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,75 @@
+package conditions
+
+import (
+	"errors"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+)
+
+// BuiltinFunc implements a condition in Go rather than YAML. It returns an
+// error when the condition cannot be decided.
+type BuiltinFunc func(cond *config.Condition, event *HookEvent, cfg *config.Config) (bool, error)
+
+// builtins maps the names usable as `type: builtin, builtin: <name>`
+var builtins = map[string]BuiltinFunc{
+	"tamper-protection": evaluateTamper,
+	"secret-detection":  evaluateSecrets,
+	"ticket-schema":     evaluateTicketSchema,
+	"ticket-completion": evaluateTicketCompletion,
+	"protected-merge":   evaluateProtectedMerge,
+	"agent-context":     evaluateAgentContext,
+	"finishes-ticket":   evaluateFinishesTicket,
+	"task-description":  evaluateTaskDescription,
+}
+
+// builtinCaptures lists the fields each builtin records for message
+// templates, so config validation can recognise them
+var builtinCaptures = map[string][]string{
+	"tamper-protection": {"tamper_target", "tamper_reason"},
+	"secret-detection":  {"secret_detector", "secret_file", "secret_field", "secret_line", "secret_count"},
+	"ticket-schema":     {"ticket_file", "ticket_problems", "ticket_problem_count"},
+	"ticket-completion": {"pr_branch", "pr_ticket", "pr_ticket_path", "pr_completed_path", "pr_worktree"},
+	"protected-merge":   {"merge_op", "merge_source", "merge_target", "merge_commands"},
+	"agent-context":     {"agent_name", "agent_file", "agent_reason"},
+	"finishes-ticket":   {"finish_command"},
+	"task-description":  {"task_description", "task_problems", "task_expected", "task_agents"},
+}
+
+// BuiltinCaptures lists every field a builtin condition may capture
+func BuiltinCaptures() []string {
+	var names []string
+	for _, fields := range builtinCaptures {
+		names = append(names, fields...)
+	}
+	return names
+}
+
+// BuiltinNames lists the registered builtin conditions
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	return names
+}
+
+func evaluateBuiltin(cond *config.Condition, event *HookEvent, cfg *config.Config) (bool, error) {
+	fn, exists := builtins[cond.Builtin]
+	if !exists {
+		return false, evalError(ErrInvalidCondition, "builtin", "unknown builtin %q", cond.Builtin)
+	}
+	matched, err := fn(cond, event, cfg)
+	var evalErr *EvalError
+	if errors.As(err, &evalErr) && evalErr.Condition == "" {
+		evalErr.Condition = cond.Builtin
+	}
+	return matched, err
+}
+
+// capture records a detail for message templates
+func (e *HookEvent) capture(key string, value any) {
+	if e.Captures == nil {
+		e.Captures = make(map[string]any)
+	}
+	e.Captures[key] = value
+}
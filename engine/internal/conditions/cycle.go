@@ -0,0 +1,113 @@
+package conditions
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/cycle"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/shell"
+)
+
+// cycleFields describes the quality cycle of the current ticket as
+// cycle.*: ticket, started, cycle, created, approved (a critic approved
+// since the last creator run), accepted (an expediter approved after
+// that), complete, pending (the stage to run next), next (its agent in the
+// same cycle), last_agent, last_event, last_verdict and history. The
+// transitions are those recorded by cycle actions this session.
+func cycleFields(e *HookEvent) (any, error) {
+	// Read ticket.* directly: going through Field would make the providers
+	// table refer to itself
+	t, ok := e.Raw["ticket"].(map[string]any)
+	if !ok {
+		value, err := ticketFields(e)
+		if err != nil {
+			return map[string]any{"started": false}, err
+		}
+		t, _ = value.(map[string]any)
+		if e.Raw != nil {
+			e.Raw["ticket"] = t
+		}
+	}
+	path, _ := t["path"].(string)
+	if path == "" || e.Session == nil {
+		return map[string]any{"started": false}, nil
+	}
+
+	key := cycle.Key(path)
+	history := cycle.History(e.Session.Values, key)
+	s := cycle.Evaluate(history)
+	steps := make([]string, 0, len(history))
+	for _, h := range history {
+		step := h.Agent + " " + h.Event
+		if h.Verdict != "" {
+			step += " " + h.Verdict
+		}
+		steps = append(steps, step)
+	}
+	fields := map[string]any{
+		"ticket":   key,
+		"started":  s.Started,
+		"cycle":    s.Cycle,
+		"created":  s.Created,
+		"approved": s.Approved,
+		"accepted": s.Accepted,
+		"complete": s.Pending == "",
+		"pending":  s.Pending,
+		"next":     stageAgent(e.agents, s.Cycle, s.Pending),
+		"history":  steps,
+	}
+	if s.Last != nil {
+		fields["last_agent"] = s.Last.Agent
+		fields["last_event"] = s.Last.Event
+		fields["last_verdict"] = s.Last.Verdict
+	}
+	return fields, nil
+}
+
+// stageAgent returns the agent that holds a stage of a cycle, the first by
+// name when several do
+func stageAgent(agents config.AgentSettings, cycleName, stage string) string {
+	var names []string
+	for name, role := range agents.Roles {
+		if stage != "" && role.Stage == stage && role.Cycle == cycleName {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+// evaluateFinishesTicket matches finishing the current branch's ticket:
+// hookctl ticket complete in a Bash command, or opening a pull request
+// (gh pr create, an MCP create_pull_request tool) for the current branch.
+// Pull requests naming another --head are left to that branch. It records
+// finish_command.
+func evaluateFinishesTicket(cond *config.Condition, event *HookEvent, cfg *config.Config) (bool, error) {
+	if event.ToolName == "Bash" {
+		command, _ := event.ToolInput["command"].(string)
+		for _, cmd := range shell.Parse(command) {
+			args := cmd.Args
+			if len(args) >= 3 && filepath.Base(args[0]) == "hookctl" && args[1] == "ticket" && args[2] == "complete" {
+				event.capture("finish_command", "hookctl ticket complete")
+				return true, nil
+			}
+		}
+	}
+	head, ok := pullRequestHead(event)
+	if !ok {
+		return false, nil
+	}
+	if current, _ := event.Field("git.branch").(string); head != "" && head != current {
+		return false, nil
+	}
+	command := "gh pr create"
+	if event.ToolName != "Bash" {
+		command = event.ToolName
+	}
+	event.capture("finish_command", command)
+	return true, nil
+}
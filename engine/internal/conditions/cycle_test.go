@@ -0,0 +1,97 @@
+package conditions
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/cycle"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/session"
+)
+
+func TestCycleFields(t *testing.T) {
+	cfg := agentConfig()
+	cfg.Settings.Agents.Roles["code-tester"] = config.AgentRole{Cycle: "code", Stage: cycle.Expediter}
+	const ticketPath = "/work/wg/tickets/active/TICKET-s1-001.md"
+
+	developer := cycle.Transition{Agent: "code-developer", Cycle: "code", Stage: cycle.Creator, Event: cycle.Start}
+	approve := cycle.Transition{Agent: "code-reviewer", Cycle: "code", Stage: cycle.Critic, Event: cycle.Finish, Verdict: cycle.Approved}
+	reject := approve
+	reject.Verdict = cycle.ChangesNeeded
+	accept := cycle.Transition{Agent: "code-tester", Cycle: "code", Stage: cycle.Expediter, Event: cycle.Finish, Verdict: cycle.Approved}
+
+	tests := []struct {
+		name    string
+		history []cycle.Transition
+		want    map[string]any
+	}{
+		{"not started", nil, map[string]any{
+			"started": false, "approved": false, "pending": cycle.Critic, "next": "",
+		}},
+		{"awaiting review", []cycle.Transition{developer}, map[string]any{
+			"started": true, "created": true, "approved": false, "pending": cycle.Critic, "next": "code-reviewer",
+		}},
+		{"approved", []cycle.Transition{developer, approve}, map[string]any{
+			"approved": true, "accepted": false, "complete": false, "pending": cycle.Expediter, "next": "code-tester",
+			"last_agent": "code-reviewer", "last_verdict": cycle.Approved,
+		}},
+		{"complete", []cycle.Transition{developer, approve, accept}, map[string]any{
+			"approved": true, "accepted": true, "complete": true, "pending": "", "next": "",
+		}},
+		{"creator restarted", []cycle.Transition{developer, approve, accept, developer}, map[string]any{
+			"approved": false, "accepted": false, "complete": false, "pending": cycle.Critic,
+			"last_agent": "code-developer", "last_event": cycle.Start,
+		}},
+		{"expediter before approval", []cycle.Transition{developer, accept}, map[string]any{
+			"approved": false, "accepted": false, "pending": cycle.Critic,
+		}},
+		{"last verdict wins", []cycle.Transition{developer, approve, reject}, map[string]any{
+			"approved": false, "pending": cycle.Critic, "last_verdict": cycle.ChangesNeeded,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, release := session.NewStore(t.TempDir(), false).Acquire("s1")
+			defer release()
+			for _, step := range tt.history {
+				cycle.Record(state, "TICKET-s1-001", step)
+			}
+			event, err := NewEvent([]byte(`{"hook_event_name":"PreToolUse","session_id":"s1"}`), map[string]string{}, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			event.AttachSession(state)
+			event.Raw["ticket"] = map[string]any{"found": true, "path": ticketPath}
+
+			if got := event.Field("cycle.ticket"); got != "TICKET-s1-001" {
+				t.Errorf("cycle.ticket = %v", got)
+			}
+			for field, want := range tt.want {
+				if got := event.Field("cycle." + field); got != want {
+					t.Errorf("cycle.%s = %v, want %v", field, got, want)
+				}
+			}
+		})
+	}
+
+	// History reads back as "agent event [verdict]"
+	state, release := session.NewStore(t.TempDir(), false).Acquire("s1")
+	defer release()
+	cycle.Record(state, "TICKET-s1-001", developer)
+	cycle.Record(state, "TICKET-s1-001", approve)
+	event, _ := NewEvent([]byte(`{"hook_event_name":"Stop","session_id":"s1"}`), map[string]string{}, cfg)
+	event.AttachSession(state)
+	event.Raw["ticket"] = map[string]any{"found": true, "path": ticketPath}
+	want := []string{"code-developer start", "code-reviewer finish approved"}
+	if got := event.Field("cycle.history"); !reflect.DeepEqual(got, want) {
+		t.Errorf("cycle.history = %v, want %v", got, want)
+	}
+
+	// Without a ticket there is no cycle
+	event, _ = NewEvent([]byte(`{"hook_event_name":"Stop","session_id":"s1"}`), map[string]string{}, cfg)
+	event.AttachSession(state)
+	event.Raw["ticket"] = map[string]any{"found": false}
+	if got := event.Field("cycle.started"); got != false {
+		t.Errorf("cycle.started without a ticket = %v", got)
+	}
+}
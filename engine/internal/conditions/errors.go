@@ -0,0 +1,63 @@
+package conditions
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of evaluation failure, for use with errors.Is
+var (
+	// ErrInvalidCondition is an unknown reference, type or builtin
+	ErrInvalidCondition = errors.New("invalid condition")
+	// ErrInvalidPattern is a regex or glob that does not compile
+	ErrInvalidPattern = errors.New("invalid pattern")
+	// ErrScriptFailed is a script condition that could not run or exited
+	// with a status other than 0 (match) or 1 (no match)
+	ErrScriptFailed = errors.New("script failed")
+	// ErrScriptTimeout is a script condition that ran past its timeout
+	ErrScriptTimeout = errors.New("script timed out")
+	// ErrTranscript is a transcript that exists but cannot be read
+	ErrTranscript = errors.New("transcript unreadable")
+	// ErrTicket is a tickets/ directory or ticket file that cannot be read
+	ErrTicket = errors.New("ticket unreadable")
+)
+
+// EvalError reports a condition that could not be evaluated. Evaluate
+// returns it instead of treating the condition as false, so rules can fail
+// open or closed as configured.
+type EvalError struct {
+	Kind      error  // One of the Err* values above
+	Ref       string // Innermost named condition containing the failure
+	Condition string // Type, builtin or script of the failing condition
+	Err       error  // Underlying cause, if any
+}
+
+func (e *EvalError) Error() string {
+	msg := e.Kind.Error()
+	switch {
+	case e.Ref != "" && e.Condition != "":
+		msg = fmt.Sprintf("%s (%s): %s", e.Ref, e.Condition, msg)
+	case e.Ref != "" || e.Condition != "":
+		msg = e.Ref + e.Condition + ": " + msg
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap exposes both the kind and the cause to errors.Is and errors.As
+func (e *EvalError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+func evalError(kind error, condition string, format string, args ...any) *EvalError {
+	var err error
+	if format != "" {
+		err = fmt.Errorf(format, args...)
+	}
+	return &EvalError{Kind: kind, Condition: condition, Err: err}
+}
@@ -1,65 +1,146 @@
 package conditions
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"regexp"
 	"strings"
 
 	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/session"
 )
 
 // HookEvent represents an incoming hook event
 type HookEvent struct {
 	HookType  string                 `json:"hook_type"`
+	EventName string                 `json:"hook_event_name"`
 	ToolName  string                 `json:"tool_name"`
 	ToolInput map[string]interface{} `json:"tool_input"`
 	SessionID string                 `json:"session_id"`
-	Raw       map[string]interface{} `json:"-"`
+	Cwd       string                 `json:"cwd"`
+	Prompt    string                 `json:"prompt"`
+	// ToolResponse is the tool's result on PostToolUse
+	ToolResponse any `json:"tool_response"`
+	// ToolUseID pairs a tool call's PreToolUse and PostToolUse events
+	ToolUseID string `json:"tool_use_id"`
+	// TranscriptPath is the session's JSONL conversation log
+	TranscriptPath string `json:"transcript_path"`
+	// StopHookActive is set on Stop/SubagentStop when Claude is already
+	// continuing because a stop hook blocked before
+	StopHookActive bool                   `json:"stop_hook_active"`
+	Raw            map[string]interface{} `json:"-"`
+	Env            map[string]string      `json:"-"`
+	Session        *session.State         `json:"-"`
+	// DryRun skips actions that reach outside the response (log files,
+	// webhooks), as `hookctl test --dry-run` does
+	DryRun bool `json:"-"`
+	// ctx is cancelled when the caller stops waiting for the response
+	ctx context.Context
+	// Captures holds details recorded by builtin conditions (e.g. which
+	// path matched) for use in action message templates
+	Captures  map[string]any `json:"-"`
+	envAllow  []string
+	agents    config.AgentSettings
+	fieldErrs map[string]error // Provider failures, by field root
 }
 
-// Evaluate evaluates a condition against a hook event
-func Evaluate(cond *config.Condition, event *HookEvent, cfg *config.Config) bool {
+// Context is cancelled once nobody waits for the event's response, as when
+// a forwarding client's deadline passes. Scripts and webhooks stop with it.
+func (e *HookEvent) Context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
+}
+
+// SetContext sets the context returned by Context
+func (e *HookEvent) SetContext(ctx context.Context) {
+	e.ctx = ctx
+}
+
+// Evaluate evaluates a condition against a hook event. A condition that
+// cannot be evaluated (bad pattern, failing script, unreadable transcript)
+// returns an *EvalError rather than false. Compound conditions only fail
+// when the error decides the outcome: a false clause of all, or a true
+// clause of any, settles the result regardless of the others.
+func Evaluate(cond *config.Condition, event *HookEvent, cfg *config.Config) (bool, error) {
 	if cond == nil {
-		return true // Empty condition always matches
+		return true, nil // Empty condition always matches
 	}
 
 	// Handle condition reference
 	if cond.Ref != "" {
 		refCond, exists := cfg.Conditions[cond.Ref]
 		if !exists {
-			return false
+			return false, evalError(ErrInvalidCondition, "", "unknown condition reference %q", cond.Ref)
 		}
 		// Merge referenced condition with any overrides
 		merged := mergeCondition(refCond, *cond)
-		return Evaluate(&merged, event, cfg)
+		matched, err := Evaluate(&merged, event, cfg)
+		var evalErr *EvalError
+		if errors.As(err, &evalErr) && evalErr.Ref == "" {
+			evalErr.Ref = cond.Ref
+		}
+		return matched, err
 	}
 
 	// Compound conditions
 	if len(cond.All) > 0 {
+		var firstErr error
 		for _, c := range cond.All {
-			if !Evaluate(&c, event, cfg) {
-				return false
+			matched, err := Evaluate(&c, event, cfg)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			if !matched {
+				return false, nil
 			}
 		}
-		return true
+		return firstErr == nil, firstErr
 	}
 
 	if len(cond.Any) > 0 {
+		var firstErr error
 		for _, c := range cond.Any {
-			if Evaluate(&c, event, cfg) {
-				return true
+			matched, err := Evaluate(&c, event, cfg)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			if matched {
+				return true, nil
 			}
 		}
-		return false
+		return false, firstErr
 	}
 
 	if cond.Not != nil {
-		return !Evaluate(cond.Not, event, cfg)
+		matched, err := Evaluate(cond.Not, event, cfg)
+		if err != nil {
+			return false, err
+		}
+		return !matched, nil
+	}
+
+	if cond.Type == "builtin" {
+		return evaluateBuiltin(cond, event, cfg)
+	}
+	if cond.Type == "script" {
+		return evaluateScript(cond, event, cfg)
 	}
 
 	// Field-based conditions
-	fieldValue := getFieldValue(event.Raw, cond.Field)
+	fieldValue, err := event.FieldErr(cond.Field)
+	if err != nil {
+		return false, err
+	}
 
 	switch cond.Type {
 	case "regex":
@@ -69,9 +150,9 @@ func Evaluate(cond *config.Condition, event *HookEvent, cfg *config.Config) bool
 	case "equals":
 		return evaluateEquals(cond, fieldValue)
 	case "exists":
-		return fieldValue != nil
+		return fieldValue != nil, nil
 	default:
-		return false
+		return false, evalError(ErrInvalidCondition, cond.Type, "unknown condition type %q", cond.Type)
 	}
 }
 
@@ -121,9 +202,9 @@ func getFieldValue(data map[string]interface{}, fieldPath string) interface{} {
 	return current
 }
 
-func evaluateRegex(cond *config.Condition, fieldValue interface{}) bool {
+func evaluateRegex(cond *config.Condition, fieldValue interface{}) (bool, error) {
 	if fieldValue == nil {
-		return false
+		return false, nil
 	}
 
 	valueStr := fmt.Sprintf("%v", fieldValue)
@@ -141,20 +222,20 @@ func evaluateRegex(cond *config.Condition, fieldValue interface{}) bool {
 
 	matched, err := regexp.MatchString(pattern, valueStr)
 	if err != nil {
-		return false
+		return false, &EvalError{Kind: ErrInvalidPattern, Condition: "regex", Err: err}
 	}
-	return matched
+	return matched, nil
 }
 
-func evaluateGlob(cond *config.Condition, fieldValue interface{}) bool {
+func evaluateGlob(cond *config.Condition, fieldValue interface{}) (bool, error) {
 	if fieldValue == nil {
-		return false
+		return false, nil
 	}
 
 	valueStr := fmt.Sprintf("%v", fieldValue)
 	matched, err := filepath.Match(cond.Pattern, filepath.Base(valueStr))
 	if err != nil {
-		return false
+		return false, evalError(ErrInvalidPattern, "glob", "%q: %v", cond.Pattern, err)
 	}
 
 	// Also check full path match for patterns with **
@@ -162,16 +243,16 @@ func evaluateGlob(cond *config.Condition, fieldValue interface{}) bool {
 		// Simple ** implementation
 		pattern := strings.ReplaceAll(cond.Pattern, "**", "*")
 		if m, _ := filepath.Match(pattern, valueStr); m {
-			return true
+			return true, nil
 		}
 	}
 
-	return matched
+	return matched, nil
 }
 
-func evaluateEquals(cond *config.Condition, fieldValue interface{}) bool {
+func evaluateEquals(cond *config.Condition, fieldValue interface{}) (bool, error) {
 	if fieldValue == nil {
-		return false
+		return false, nil
 	}
 
 	fieldStr := fmt.Sprintf("%v", fieldValue)
@@ -183,14 +264,14 @@ func evaluateEquals(cond *config.Condition, fieldValue interface{}) bool {
 
 	switch operator {
 	case "equals":
-		return fieldStr == valueStr
+		return fieldStr == valueStr, nil
 	case "startswith":
-		return strings.HasPrefix(fieldStr, valueStr)
+		return strings.HasPrefix(fieldStr, valueStr), nil
 	case "endswith":
-		return strings.HasSuffix(fieldStr, valueStr)
+		return strings.HasSuffix(fieldStr, valueStr), nil
 	case "contains":
-		return strings.Contains(fieldStr, valueStr)
+		return strings.Contains(fieldStr, valueStr), nil
 	default:
-		return false
+		return false, evalError(ErrInvalidCondition, "equals", "unknown operator %q", operator)
 	}
 }
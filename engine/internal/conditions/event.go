@@ -0,0 +1,102 @@
+package conditions
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/session"
+)
+
+// NewEvent parses a hook event and prepares its raw field map.
+// env is the environment of the hook invocation, which may differ from the
+// current process when the event was forwarded to the dispatcher daemon.
+// Only variables allowed by the config's env settings are exposed as env.*.
+func NewEvent(data []byte, env map[string]string, cfg *config.Config) (*HookEvent, error) {
+	var event HookEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+	if env == nil {
+		env = Environ()
+	}
+	event.Env = env
+	event.envAllow = cfg.Settings.Env.Allow
+	event.agents = cfg.Settings.Agents
+
+	// Claude Code names the event hook_event_name
+	if event.HookType == "" {
+		event.HookType = event.EventName
+	}
+
+	// Also check environment for hook type
+	if envHookType := env["CLAUDE_HOOK_TYPE"]; envHookType != "" {
+		event.HookType = envHookType
+	}
+
+	event.Refresh()
+	return &event, nil
+}
+
+// Refresh rebuilds the raw field map used for condition field access
+func (e *HookEvent) Refresh() {
+	e.Raw = make(map[string]any)
+	e.fieldErrs = nil
+	e.Raw["hook_type"] = e.HookType
+	e.Raw["tool_name"] = e.ToolName
+	e.Raw["session_id"] = e.SessionID
+	e.Raw["tool_input"] = e.ToolInput
+	e.Raw["tool_response"] = e.ToolResponse
+	e.Raw["cwd"] = e.Cwd
+	e.Raw["prompt"] = e.Prompt
+	e.Raw["stop_hook_active"] = e.StopHookActive
+	e.Raw["transcript_path"] = e.TranscriptPath
+
+	// Add allowlisted environment variables for condition evaluation
+	envMap := make(map[string]any)
+	for name, value := range e.Env {
+		if value != "" && envAllowed(name, e.envAllow) {
+			envMap[name] = value
+		}
+	}
+	e.Raw["env"] = envMap
+
+	if e.Session != nil {
+		e.Raw["session"] = e.Session.Values
+	}
+}
+
+// AttachSession makes session state available to conditions and actions
+func (e *HookEvent) AttachSession(state *session.State) {
+	e.Session = state
+	if e.Raw != nil && state != nil {
+		e.Raw["session"] = state.Values
+	}
+}
+
+// envAllowed reports whether a variable name matches an allowlist entry.
+// Entries are exact names or path.Match globs such as WORKFLOW_GUARD_*.
+func envAllowed(name string, allow []string) bool {
+	for _, pattern := range allow {
+		if pattern == name {
+			return true
+		}
+		if matched, err := path.Match(pattern, name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Environ returns the current process environment as a map
+func Environ() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			env[k] = v
+		}
+	}
+	return env
+}
@@ -0,0 +1,46 @@
+package conditions
+
+import (
+	"testing"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+)
+
+func TestEventEnvAllowlist(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Settings.Env.Allow = []string{"SKIP_EDIT_CONFIRMATION", "WORKFLOW_GUARD_*", "CI"}
+	env := map[string]string{
+		"SKIP_EDIT_CONFIRMATION": "true",
+		"WORKFLOW_GUARD_PROFILE": "hotfix",
+		"WORKFLOW_GUARD_EMPTY":   "",
+		"CI":                     "1",
+		"CIRCLECI":               "1",
+		"AWS_SECRET_ACCESS_KEY":  "secret",
+	}
+	event, err := NewEvent([]byte(`{"hook_event_name":"PreToolUse"}`), env, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		field string
+		want  any
+	}{
+		{"env.SKIP_EDIT_CONFIRMATION", "true"},
+		{"env.WORKFLOW_GUARD_PROFILE", "hotfix"},
+		{"env.CI", "1"},
+		{"env.WORKFLOW_GUARD_EMPTY", nil},
+		{"env.CIRCLECI", nil},
+		{"env.AWS_SECRET_ACCESS_KEY", nil},
+	}
+	for _, tt := range tests {
+		if got := event.Field(tt.field); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.field, got, tt.want)
+		}
+	}
+	// Conditions can't see it, but the full environment still reaches
+	// scripts and the engine itself
+	if event.Env["AWS_SECRET_ACCESS_KEY"] != "secret" {
+		t.Error("the event lost variables outside the allowlist")
+	}
+}
@@ -0,0 +1,375 @@
+package conditions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/gitstate"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/shell"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/ticket"
+)
+
+// gitGlobalValueFlags are git options before the subcommand that take the
+// next argument as their value
+var gitGlobalValueFlags = map[string]bool{
+	"-C": true, "-c": true, "--git-dir": true, "--work-tree": true, "--namespace": true, "--config-env": true,
+}
+
+// mergeValueFlags are options of merge (and so of pull) that take the next
+// argument as their value
+var mergeValueFlags = map[string]bool{
+	"-m": true, "--message": true, "-F": true, "--file": true, "-s": true, "--strategy": true,
+	"-X": true, "--strategy-option": true, "--into-name": true,
+}
+
+// gitValueFlags are the value-taking options of each checked subcommand
+var gitValueFlags = map[string]map[string]bool{
+	"merge": mergeValueFlags,
+	"pull": union(mergeValueFlags, map[string]bool{
+		"--depth": true, "--deepen": true, "--shallow-since": true, "--shallow-exclude": true,
+		"-j": true, "--jobs": true, "--upload-pack": true, "-o": true, "--server-option": true,
+		"--negotiation-tip": true,
+	}),
+	"rebase": {
+		"--onto": true, "-s": true, "--strategy": true, "-X": true, "--strategy-option": true,
+		"-x": true, "--exec": true,
+	},
+	"push":     {"-o": true, "--push-option": true, "--repo": true, "--receive-pack": true, "--exec": true},
+	"checkout": {"-b": true, "-B": true, "--orphan": true},
+	"switch":   {"-c": true, "-C": true, "--create": true, "--force-create": true, "--orphan": true},
+}
+
+// evaluateProtectedMerge matches Bash commands that bring another branch
+// into a protected branch (CLAUDE_PROTECTED_BRANCHES) without a pull
+// request: git merge or git rebase while on it, git pull of another
+// branch into it, and git push of another branch or HEAD onto it (git push
+// origin HEAD:main). Arguments are parsed, never evaluated; refs such as
+// origin/main or refs/heads/main resolve to their branch, and a git
+// checkout or switch earlier in the line changes the current branch. It
+// records merge_op, merge_source, merge_target and merge_commands (the PR
+// workflow to use instead). A checkout of paths leaves the branch as it
+// was, and so does a switch to a branch that can't be told (git switch -,
+// git checkout "$b"), so a later merge is still checked against it.
+func evaluateProtectedMerge(cond *config.Condition, event *HookEvent, cfg *config.Config) (bool, error) {
+	if event.ToolName != "Bash" {
+		return false, nil
+	}
+	command, _ := event.ToolInput["command"].(string)
+	current, _ := event.Field("git.branch").(string)
+	root, _ := event.Field("git.root").(string)
+
+	for _, cmd := range shell.Parse(command) {
+		inv, ok := parseGit(cmd.Args)
+		if !ok {
+			continue
+		}
+		repo := gitRepo{root: root, branch: current}
+		if inv.dir != "" {
+			state, _ := gitstate.Read(resolvePath(inv.dir, event))
+			repo = gitRepo{root: state.Root, branch: state.Branch}
+		}
+
+		if inv.sub == "checkout" || inv.sub == "switch" {
+			if target, ok := switchTarget(inv, event); ok {
+				current = target
+			}
+			continue
+		}
+		positional, flags := splitGitArgs(inv.args, gitValueFlags[inv.sub])
+		var m *protectedMerge
+		switch inv.sub {
+		case "merge":
+			m = repo.merge(positional, flags)
+		case "rebase":
+			m = repo.rebase(positional, flags)
+		case "pull":
+			m = repo.pull(positional)
+		case "push":
+			m = repo.push(positional, flags)
+		}
+		if m == nil {
+			continue
+		}
+		event.capture("merge_op", inv.sub)
+		event.capture("merge_source", m.source)
+		event.capture("merge_target", m.target)
+		event.capture("merge_commands", m.commands(repo.branch))
+		return true, nil
+	}
+	return false, nil
+}
+
+// gitInvocation is a git command line without git's global options
+type gitInvocation struct {
+	dir  string // -C directory, "" for the event's working directory
+	sub  string
+	args []string
+}
+
+func parseGit(args []string) (gitInvocation, bool) {
+	var inv gitInvocation
+	if len(args) < 2 || filepath.Base(args[0]) != "git" {
+		return inv, false
+	}
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-C" && i+1 < len(args):
+			i++
+			if filepath.IsAbs(args[i]) {
+				inv.dir = args[i]
+			} else {
+				inv.dir = filepath.Join(inv.dir, args[i])
+			}
+		case gitGlobalValueFlags[arg]:
+			i++
+		case strings.HasPrefix(arg, "-"):
+		default:
+			inv.sub, inv.args = arg, args[i+1:]
+			return inv, true
+		}
+	}
+	return inv, false
+}
+
+// splitGitArgs separates positional arguments from options. Options are
+// returned with their value ("" for switches); everything after -- is
+// positional.
+func splitGitArgs(args []string, valueFlags map[string]bool) ([]string, map[string]string) {
+	var positional []string
+	flags := map[string]string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return append(positional, args[i+1:]...), flags
+		case strings.HasPrefix(arg, "--") && strings.Contains(arg, "="):
+			name, value, _ := strings.Cut(arg, "=")
+			flags[name] = value
+		case valueFlags[arg] && i+1 < len(args):
+			flags[arg] = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			flags[arg] = ""
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	return positional, flags
+}
+
+// switchTarget returns the branch a checkout or switch in the event's
+// repository moves to, "" for a detached HEAD. It returns false when the
+// command doesn't switch (git checkout -- file, git checkout main file,
+// git checkout file) or the target can't be told (git switch -, a
+// variable), so the current branch is kept.
+func switchTarget(inv gitInvocation, event *HookEvent) (string, bool) {
+	if inv.dir != "" || containsAny(inv.args, "--") {
+		return "", false
+	}
+	positional, flags := splitGitArgs(inv.args, gitValueFlags[inv.sub])
+	for _, flag := range []string{"-b", "-B", "-c", "-C", "--create", "--force-create", "--orphan"} {
+		if branch, ok := flags[flag]; ok {
+			return branch, !strings.ContainsAny(branch, "$`")
+		}
+	}
+	if hasAny(flags, "-p", "--patch", "--pathspec-from-file") || len(positional) > 1 {
+		return "", false
+	}
+	if hasAny(flags, "--detach") || (inv.sub == "switch" && hasAny(flags, "-d")) {
+		return "", true
+	}
+	if len(positional) == 0 {
+		return "", false
+	}
+	target := positional[0]
+	if target == "-" || strings.ContainsAny(target, "$`") {
+		return "", false
+	}
+	// git checkout reads a lone name as a path when such a file exists. Git
+	// prefers a branch of the same name; keeping the current branch then
+	// only errs toward checking the merge.
+	if inv.sub == "checkout" {
+		if _, err := os.Stat(resolvePath(target, event)); err == nil {
+			return "", false
+		}
+	}
+	return target, true
+}
+
+// protectedMerge is another branch being brought into a protected one
+type protectedMerge struct {
+	source string
+	target string
+	remote string
+}
+
+// commands returns the pull request workflow for the change: sync the
+// source branch with the target, push it and open the PR
+func (m *protectedMerge) commands(current string) []string {
+	var commands []string
+	if m.source != current {
+		commands = append(commands, "git checkout "+m.source)
+	}
+	return append(commands,
+		fmt.Sprintf("git fetch %s %s && git merge %s/%s", m.remote, m.target, m.remote, m.target),
+		fmt.Sprintf("git push -u %s %s", m.remote, m.source),
+		fmt.Sprintf("gh pr create --base %s --head %s", m.target, m.source),
+	)
+}
+
+// gitRepo resolves refs of the repository a git command runs in
+type gitRepo struct {
+	root    string
+	branch  string // Current branch, "" when unknown or detached
+	remotes []string
+}
+
+// merge: on a protected branch, merging anything but a protected branch
+func (r *gitRepo) merge(positional []string, flags map[string]string) *protectedMerge {
+	if hasAny(flags, "--abort", "--continue", "--quit") || !isProtected(r.branch) {
+		return nil
+	}
+	for _, ref := range positional {
+		if source := r.branchName(ref); !isProtected(source) {
+			return &protectedMerge{source: source, target: r.branch, remote: "origin"}
+		}
+	}
+	return nil
+}
+
+// rebase: replaying a protected branch onto anything but a protected
+// branch (git rebase <upstream> [<branch>], --onto <newbase>)
+func (r *gitRepo) rebase(positional []string, flags map[string]string) *protectedMerge {
+	if hasAny(flags, "--abort", "--continue", "--skip", "--quit", "--edit-todo", "--show-current-patch") {
+		return nil
+	}
+	branch := r.branch
+	if len(positional) >= 2 {
+		branch = r.branchName(positional[1])
+	}
+	upstream, ok := flags["--onto"]
+	if !ok && len(positional) > 0 {
+		upstream = positional[0]
+	}
+	if upstream == "" || !isProtected(branch) {
+		return nil
+	}
+	// HEAD~n rewrites the branch's own commits rather than bringing others in
+	if source := r.branchName(upstream); source != "HEAD" && source != "@" && !isProtected(source) {
+		return &protectedMerge{source: source, target: branch, remote: "origin"}
+	}
+	return nil
+}
+
+// pull: fetching another branch into a protected one, either the current
+// branch or the destination of a src:dst refspec
+func (r *gitRepo) pull(positional []string) *protectedMerge {
+	if len(positional) < 2 {
+		return nil // The configured upstream
+	}
+	remote := positional[0]
+	for _, spec := range positional[1:] {
+		src, dst, _ := strings.Cut(strings.TrimPrefix(spec, "+"), ":")
+		target := r.branch
+		if dst != "" {
+			target = r.branchName(dst)
+		}
+		if source := r.branchName(src); src != "" && isProtected(target) && !isProtected(source) {
+			return &protectedMerge{source: source, target: target, remote: remote}
+		}
+	}
+	return nil
+}
+
+// push: updating a protected branch from a different branch, commit or
+// HEAD. Pushing a protected branch to itself is left alone; deletions and
+// --all/--mirror are out of scope.
+func (r *gitRepo) push(positional []string, flags map[string]string) *protectedMerge {
+	if hasAny(flags, "--all", "--branches", "--mirror", "--delete", "-d") || len(positional) < 2 {
+		return nil
+	}
+	remote := positional[0]
+	for _, spec := range positional[1:] {
+		src, dst, hasDst := strings.Cut(strings.TrimPrefix(spec, "+"), ":")
+		if src == "" {
+			continue // :branch deletes
+		}
+		if !hasDst {
+			dst = src
+		}
+		source, target := r.pushRef(src), r.pushRef(dst)
+		if isProtected(target) && !strings.EqualFold(source, target) {
+			if source == "" {
+				source = src
+			}
+			return &protectedMerge{source: source, target: target, remote: remote}
+		}
+	}
+	return nil
+}
+
+// pushRef resolves a push refspec side, where HEAD is the current branch
+func (r *gitRepo) pushRef(ref string) string {
+	if ref == "HEAD" || ref == "@" {
+		return r.branch
+	}
+	return r.branchName(ref)
+}
+
+// branchName resolves a ref to the branch it names: refs/heads/x,
+// refs/remotes/origin/x, origin/x and x~2 all give x
+func (r *gitRepo) branchName(ref string) string {
+	if i := strings.IndexAny(ref, "~^"); i > 0 {
+		ref = ref[:i]
+	}
+	ref = strings.TrimPrefix(ref, "refs/heads/")
+	if rest, ok := strings.CutPrefix(ref, "refs/remotes/"); ok {
+		if _, branch, ok := strings.Cut(rest, "/"); ok {
+			return branch
+		}
+		return rest
+	}
+	if remote, branch, ok := strings.Cut(ref, "/"); ok && r.isRemote(remote) {
+		return branch
+	}
+	return ref
+}
+
+func (r *gitRepo) isRemote(name string) bool {
+	if r.remotes == nil {
+		r.remotes = []string{"origin"}
+		if r.root != "" {
+			if out, err := gitstate.Git(r.root, "remote"); err == nil && out != "" {
+				r.remotes = append(r.remotes, strings.Split(out, "\n")...)
+			}
+		}
+	}
+	return containsAny(r.remotes, name)
+}
+
+func isProtected(branch string) bool {
+	return branch != "" && ticket.IsProtectedBranch(branch)
+}
+
+func hasAny(flags map[string]string, names ...string) bool {
+	for _, name := range names {
+		if _, ok := flags[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func union(sets ...map[string]bool) map[string]bool {
+	all := map[string]bool{}
+	for _, set := range sets {
+		for key := range set {
+			all[key] = true
+		}
+	}
+	return all
+}
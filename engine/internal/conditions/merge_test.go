@@ -0,0 +1,84 @@
+package conditions
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+)
+
+func TestProtectedMerge(t *testing.T) {
+	t.Setenv("CLAUDE_PROTECTED_BRANCHES", "")
+	cwd := t.TempDir()
+	for _, name := range []string{"README.md", "a.txt"} {
+		if err := os.WriteFile(filepath.Join(cwd, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		branch  string
+		command string
+		want    string // merge_source, "" for no match
+	}{
+		{"merge on main", "main", "git merge feature", "feature"},
+		{"merge remote branch", "main", "git merge --no-ff origin/feature", "feature"},
+		{"merge protected branch", "main", "git merge origin/main", ""},
+		{"merge on feature", "feature", "git merge main", ""},
+		{"merge abort", "main", "git merge --abort", ""},
+		{"rebase main onto feature", "main", "git rebase feature", "feature"},
+		{"rebase named branch", "feature", "git rebase feature main", "feature"},
+		{"rebase own commits", "main", "git rebase -i HEAD~3", ""},
+		{"rebase feature onto main", "feature", "git rebase main", ""},
+		{"pull other branch", "main", "git pull origin feature", "feature"},
+		{"pull upstream", "main", "git pull", ""},
+		{"pull into main refspec", "feature", "git pull origin feature:main", "feature"},
+		{"push HEAD to main", "feature", "git push origin HEAD:main", "feature"},
+		{"push branch to main", "feature", "git push origin feature:refs/heads/main", "feature"},
+		{"push main", "main", "git push origin main", ""},
+		{"push feature", "feature", "git push -u origin feature", ""},
+
+		{"checkout then merge", "feature", "git checkout main && git merge feature", "feature"},
+		{"switch then merge", "feature", "git switch main && git merge feature", "feature"},
+		{"create branch then merge", "main", "git checkout -b topic && git merge feature", ""},
+		{"checkout away then merge", "main", "git checkout feature && git merge main", ""},
+		{"path after --", "main", "git checkout -- README.md && git merge feature", "feature"},
+		{"branch and path", "main", "git checkout feature -- a.txt && git merge feature", "feature"},
+		{"branch and path without --", "main", "git checkout feature a.txt && git merge feature", "feature"},
+		{"lone existing file", "main", "git checkout README.md && git merge feature", "feature"},
+		{"patch checkout", "main", "git checkout -p feature && git merge feature", "feature"},
+		{"previous branch", "main", "git switch - && git merge feature", "feature"},
+		{"variable target", "main", "git checkout \"$B\" && git merge feature", "feature"},
+		{"detached", "main", "git switch --detach && git merge feature", ""},
+		{"other repository", "main", "git -C ../other checkout feature && git merge feature", "feature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(map[string]any{
+				"hook_event_name": "PreToolUse",
+				"tool_name":       "Bash",
+				"tool_input":      map[string]any{"command": tt.command},
+				"cwd":             cwd,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			event, err := NewEvent(data, map[string]string{}, &config.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			event.Raw["git"] = map[string]any{"branch": tt.branch, "root": ""}
+			matched, err := evaluateProtectedMerge(&config.Condition{}, event, &config.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			source, _ := event.Captures["merge_source"].(string)
+			if matched != (tt.want != "") || source != tt.want {
+				t.Errorf("%q on %s: matched %v, source %q; want source %q", tt.command, tt.branch, matched, source, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package conditions
+
+import (
+	"os"
+	"strings"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/gitstate"
+)
+
+// Provider computes a group of fields on first access. Providers let
+// conditions read expensive state (git, tickets) only when a rule needs it.
+// An error means the state exists but could not be read; conditions on the
+// provider's fields then fail with it instead of seeing empty values.
+type Provider func(e *HookEvent) (any, error)
+
+// providers maps top-level field names to the provider that fills them
+var providers = map[string]Provider{
+	"git":        gitFields,
+	"transcript": transcriptFields,
+	"ticket":     ticketFields,
+	"agent":      agentFields,
+	"cycle":      cycleFields,
+}
+
+// eventFields are the top-level fields every event carries. profile is
+// added by the rule engine before evaluation, and rule_id while a rule's
+// actions run.
+var eventFields = []string{
+	"hook_type", "tool_name", "session_id", "tool_input", "tool_response", "cwd", "prompt",
+	"stop_hook_active", "transcript_path", "env", "session", "profile", "rule_id",
+}
+
+// FieldRoots lists the top-level names conditions and templates can read,
+// including those filled by providers
+func FieldRoots() []string {
+	roots := append([]string{}, eventFields...)
+	for name := range providers {
+		roots = append(roots, name)
+	}
+	return roots
+}
+
+// Field returns the value at a dotted path such as tool_input.command or
+// git.branch, running the field's provider on first use. Fields a provider
+// failed to read are nil.
+func (e *HookEvent) Field(path string) any {
+	value, _ := e.FieldErr(path)
+	return value
+}
+
+// FieldErr is Field for conditions: it also returns the error of a
+// provider that failed to read the field's group
+func (e *HookEvent) FieldErr(path string) (any, error) {
+	if path == "" {
+		return nil, nil
+	}
+	root, _, _ := strings.Cut(path, ".")
+	if _, loaded := e.Raw[root]; !loaded {
+		if provider, ok := providers[root]; ok && e.Raw != nil {
+			value, err := provider(e)
+			e.Raw[root] = value
+			if err != nil {
+				if e.fieldErrs == nil {
+					e.fieldErrs = make(map[string]error)
+				}
+				e.fieldErrs[root] = err
+			}
+		}
+	}
+	if err := e.fieldErrs[root]; err != nil {
+		return nil, err
+	}
+	return getFieldValue(e.Raw, path), nil
+}
+
+// WorkDir returns the directory the event happened in
+func (e *HookEvent) WorkDir() string {
+	if e.Cwd != "" {
+		return e.Cwd
+	}
+	if dir := e.Env["CLAUDE_PROJECT_DIR"]; dir != "" {
+		return dir
+	}
+	if dir := e.Env["PWD"]; dir != "" {
+		return dir
+	}
+	dir, _ := os.Getwd()
+	return dir
+}
+
+func gitFields(e *HookEvent) (any, error) {
+	state, ok := gitstate.Read(e.WorkDir())
+	if !ok {
+		return map[string]any{}, nil
+	}
+	fields := state.Fields()
+	fields["protected"] = isProtected(state.Branch)
+	return fields, nil
+}
@@ -0,0 +1,76 @@
+package conditions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+)
+
+// DefaultScriptTimeout applies to script conditions without a timeout
+const DefaultScriptTimeout = 2 * time.Second
+
+// evaluateScript runs an external condition with the event's fields as JSON
+// on stdin. Exit status 0 matches and 1 doesn't; any other status, or
+// running past the timeout (milliseconds), is an error. Relative paths are
+// looked up in the config's scripts directory.
+func evaluateScript(cond *config.Condition, event *HookEvent, cfg *config.Config) (bool, error) {
+	if cond.Script == "" {
+		return false, evalError(ErrInvalidCondition, "script", "no script given")
+	}
+	path := expandHome(cond.Script)
+	if !filepath.IsAbs(path) {
+		if cfg.ScriptsDir == "" {
+			return false, evalError(ErrScriptFailed, cond.Script, "no scripts directory in the config search paths")
+		}
+		path = filepath.Join(cfg.ScriptsDir, path)
+	}
+
+	timeout := DefaultScriptTimeout
+	if cond.Timeout > 0 {
+		timeout = time.Duration(cond.Timeout) * time.Millisecond
+	}
+	input, err := json.Marshal(event.Raw)
+	if err != nil {
+		return false, evalError(ErrScriptFailed, cond.Script, "encode event: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(event.Context(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Dir = event.WorkDir()
+	cmd.Env = make([]string, 0, len(event.Env))
+	for name, value := range event.Env {
+		cmd.Env = append(cmd.Env, name+"="+value)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	// Don't wait on children that inherited the script's output
+	cmd.WaitDelay = 100 * time.Millisecond
+
+	err = cmd.Run()
+	if cause := event.Context().Err(); cause != nil {
+		return false, evalError(ErrScriptFailed, cond.Script, "stopped: %v", cause)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return false, evalError(ErrScriptTimeout, cond.Script, "after %v", timeout)
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return false, nil
+	}
+	if detail := strings.TrimSpace(stderr.String()); detail != "" {
+		return false, evalError(ErrScriptFailed, cond.Script, "%v: %s", err, detail)
+	}
+	return false, &EvalError{Kind: ErrScriptFailed, Condition: cond.Script, Err: err}
+}
@@ -0,0 +1,270 @@
+package conditions
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/secrets"
+)
+
+// SecretsAllowlistFile is the allowlist looked up at the repository root
+const SecretsAllowlistFile = ".secrets-allowlist"
+
+// Inline markers that exempt a line from secret detection
+const (
+	pragmaAllowlist         = "pragma: allowlist secret"
+	pragmaAllowlistNextLine = "pragma: allowlist nextline secret"
+)
+
+// secretText is one piece of new content a tool call writes
+type secretText struct {
+	field string // tool_input field it came from
+	text  string
+	line  int // Line of the file the text starts at (1 when unknown)
+}
+
+// secretsAllowlist holds the entries of an allowlist file:
+//
+//	sha256:<hex>     a specific secret value, by hash
+//	path:<glob>      files to skip, relative to the repository root
+//	detector:<name>  a detector to ignore in this repository
+//	<regex>          secret values matching the expression
+type secretsAllowlist struct {
+	hashes    map[string]bool
+	paths     []string
+	detectors map[string]bool
+	patterns  []*regexp.Regexp
+}
+
+// evaluateSecrets matches Write/Edit/MultiEdit/NotebookEdit calls whose new
+// content contains a credential, or with field set, events whose value of
+// that field does (e.g. prompt on UserPromptSubmit). It records the detector, the file, the
+// line and the number of findings as secret_detector, secret_file,
+// secret_line and secret_count.
+//
+// Params: allowlist_file (default .secrets-allowlist at the repository
+// root), entropy (default true).
+func evaluateSecrets(cond *config.Condition, event *HookEvent, cfg *config.Config) (bool, error) {
+	texts := secretTexts(cond, event)
+	if len(texts) == 0 {
+		return false, nil
+	}
+
+	filePath, _ := event.ToolInput["file_path"].(string)
+	if filePath == "" {
+		filePath, _ = event.ToolInput["notebook_path"].(string)
+	}
+
+	allowlist := loadSecretsAllowlist(secretsAllowlistPath(cond, event))
+	if filePath != "" && allowlist.skipsPath(relativeToRoot(resolvePath(filePath, event), event)) {
+		return false, nil
+	}
+	useEntropy := true
+	if enabled, ok := cond.Params["entropy"].(bool); ok {
+		useEntropy = enabled
+	}
+
+	scanner := secrets.ForConfig(cfg)
+	var first secrets.Match
+	var firstText secretText
+	count := 0
+	for _, t := range texts {
+		lines := strings.Split(t.text, "\n")
+		for _, m := range scanner.Find(t.text) {
+			if m.Detector == "high-entropy-string" && !useEntropy {
+				continue
+			}
+			if allowlist.allows(m.Detector, t.text[m.Start:m.End]) || pragmaExempt(lines, m.Line) {
+				continue
+			}
+			count++
+			if count == 1 {
+				first, firstText = m, t
+			}
+		}
+	}
+	if count == 0 {
+		return false, nil
+	}
+
+	event.capture("secret_detector", first.Detector)
+	event.capture("secret_file", filePath)
+	event.capture("secret_field", firstText.field)
+	event.capture("secret_line", firstText.line+first.Line-1)
+	event.capture("secret_count", count)
+	return true, nil
+}
+
+// secretTexts collects the new content of a file-writing tool call, or the
+// condition's field when it names one
+func secretTexts(cond *config.Condition, event *HookEvent) []secretText {
+	input := event.ToolInput
+	var texts []secretText
+	add := func(field string, value any, line int) {
+		if text, ok := value.(string); ok && text != "" {
+			texts = append(texts, secretText{field: field, text: text, line: line})
+		}
+	}
+
+	if cond != nil && cond.Field != "" {
+		add(cond.Field, event.Field(cond.Field), 1)
+		return texts
+	}
+
+	switch event.ToolName {
+	case "Write":
+		add("content", input["content"], 1)
+	case "Edit":
+		add("new_string", input["new_string"], editLine(event, input["old_string"]))
+	case "MultiEdit":
+		edits, _ := input["edits"].([]any)
+		for _, e := range edits {
+			if edit, ok := e.(map[string]any); ok {
+				add("edits.new_string", edit["new_string"], editLine(event, edit["old_string"]))
+			}
+		}
+	case "NotebookEdit":
+		add("new_source", input["new_source"], 1)
+	default:
+		// MCP and other tools using the same field names
+		add("content", input["content"], 1)
+		add("new_string", input["new_string"], 1)
+	}
+	return texts
+}
+
+// editLine finds the line an Edit's old_string starts at in the current
+// file, so findings point at the real line rather than into the snippet
+func editLine(event *HookEvent, oldString any) int {
+	old, _ := oldString.(string)
+	filePath, _ := event.ToolInput["file_path"].(string)
+	if old == "" || filePath == "" {
+		return 1
+	}
+	data, err := os.ReadFile(resolvePath(filePath, event))
+	if err != nil {
+		return 1
+	}
+	idx := strings.Index(string(data), old)
+	if idx < 0 {
+		return 1
+	}
+	return strings.Count(string(data[:idx]), "\n") + 1
+}
+
+// pragmaExempt reports whether a 1-based line carries an allowlist pragma,
+// or follows a line with the nextline pragma
+func pragmaExempt(lines []string, line int) bool {
+	if line >= 1 && line <= len(lines) && strings.Contains(lines[line-1], pragmaAllowlist) {
+		return true
+	}
+	return line >= 2 && line-2 < len(lines) && strings.Contains(lines[line-2], pragmaAllowlistNextLine)
+}
+
+// secretsAllowlistPath returns the allowlist file for the event's project
+func secretsAllowlistPath(cond *config.Condition, event *HookEvent) string {
+	file := SecretsAllowlistFile
+	if cond != nil {
+		if custom, ok := cond.Params["allowlist_file"].(string); ok && custom != "" {
+			file = expandHome(custom)
+		}
+	}
+	if filepath.IsAbs(file) {
+		return file
+	}
+	root := projectRoot(event)
+	if root == "" {
+		return ""
+	}
+	return filepath.Join(root, file)
+}
+
+// projectRoot is the repository root, or the project directory outside git
+func projectRoot(event *HookEvent) string {
+	if root, ok := event.Field("git.root").(string); ok && root != "" {
+		return root
+	}
+	return event.Env["CLAUDE_PROJECT_DIR"]
+}
+
+func relativeToRoot(p string, event *HookEvent) string {
+	root := projectRoot(event)
+	if root == "" {
+		return p
+	}
+	if rel, err := filepath.Rel(root, p); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return p
+}
+
+func loadSecretsAllowlist(file string) *secretsAllowlist {
+	allowlist := &secretsAllowlist{hashes: map[string]bool{}, detectors: map[string]bool{}}
+	if file == "" {
+		return allowlist
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return allowlist
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "sha256:"):
+			allowlist.hashes[strings.ToLower(strings.TrimPrefix(line, "sha256:"))] = true
+		case strings.HasPrefix(line, "path:"):
+			allowlist.paths = append(allowlist.paths, strings.TrimSpace(strings.TrimPrefix(line, "path:")))
+		case strings.HasPrefix(line, "detector:"):
+			allowlist.detectors[strings.TrimSpace(strings.TrimPrefix(line, "detector:"))] = true
+		default:
+			if re, err := regexp.Compile(line); err == nil {
+				allowlist.patterns = append(allowlist.patterns, re)
+			}
+		}
+	}
+	return allowlist
+}
+
+func (a *secretsAllowlist) allows(detector, value string) bool {
+	if a.detectors[detector] {
+		return true
+	}
+	sum := sha256.Sum256([]byte(value))
+	if a.hashes[hex.EncodeToString(sum[:])] {
+		return true
+	}
+	for _, re := range a.patterns {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipsPath matches a relative file path against path: entries. A glob
+// ending in / covers everything below that directory.
+func (a *secretsAllowlist) skipsPath(rel string) bool {
+	for _, pattern := range a.paths {
+		if strings.HasSuffix(pattern, "/") && strings.HasPrefix(rel, pattern) {
+			return true
+		}
+		if matched, _ := path.Match(pattern, rel); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, path.Base(rel)); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,603 @@
+package conditions
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/audit"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/session"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/shell"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/webhook"
+)
+
+// BypassEnv lists variables that change what the hook engine enforces.
+// Setting them from a tool call is treated as tampering.
+var BypassEnv = []string{
+	"SKIP_EDIT_CONFIRMATION",
+	"WORKFLOW_GUARD_*",
+	"CLAUDE_HOOK_TYPE",
+	"CLAUDE_PROJECT_DIR",
+	"CLAUDE_PLUGIN_ROOT",
+}
+
+// protectedPath is a file or directory the agent must not modify
+type protectedPath struct {
+	path   string
+	reason string
+}
+
+// Commands whose every operand is modified
+var mutatingCommands = map[string]bool{
+	"rm": true, "rmdir": true, "unlink": true, "shred": true, "truncate": true,
+	"chmod": true, "chown": true, "chgrp": true, "chattr": true, "setfacl": true,
+	"mv": true, "touch": true, "tee": true,
+}
+
+// Commands that only modify their last operand
+var destinationCommands = map[string]bool{
+	"cp": true, "install": true, "ln": true, "rsync": true, "scp": true,
+}
+
+// Commands that only read their operands
+var readOnlyCommands = map[string]bool{
+	"cat": true, "less": true, "more": true, "head": true, "tail": true,
+	"grep": true, "egrep": true, "fgrep": true, "rg": true, "ls": true,
+	"stat": true, "file": true, "wc": true, "diff": true, "cmp": true,
+	"sha256sum": true, "sha1sum": true, "shasum": true, "md5sum": true,
+	"jq": true, "yq": true, "echo": true, "printf": true, "readlink": true,
+	"realpath": true, "cd": true, "pushd": true, "popd": true, "test": true, "[": true,
+	"dispatcher": true, "workflow-guard-dispatcher": true,
+	"bat": true, "view": true, "tree": true, "du": true, "basename": true,
+	"dirname": true,
+}
+
+// File tools and the inputs that name the file they change
+var fileToolFields = []string{"file_path", "notebook_path", "path", "source", "destination"}
+
+var mcpWriteTool = regexp.MustCompile(`(?i)^mcp__.*(write|edit|move|delete|remove|create|rename)`)
+
+// evaluateTamper matches tool calls that would modify the hook engine's own
+// configuration, binaries or hook registration, or set bypass variables.
+// It records the offending target and reason as tamper_target/tamper_reason.
+func evaluateTamper(cond *config.Condition, event *HookEvent, cfg *config.Config) (bool, error) {
+	protected := protectedPaths(event, cfg)
+
+	switch {
+	case event.ToolName == "Bash":
+		command, _ := event.ToolInput["command"].(string)
+		return tamperBash(command, protected, bypassEnv(cfg), event), nil
+
+	case isFileWriteTool(event.ToolName):
+		for _, field := range fileToolFields {
+			target, ok := event.ToolInput[field].(string)
+			if !ok || target == "" {
+				continue
+			}
+			if p, hit := matchProtected(resolvePath(target, event), protected, false); hit {
+				event.capture("tamper_target", target)
+				event.capture("tamper_reason", fmt.Sprintf("%s modifies %s (%s)", event.ToolName, p.path, p.reason))
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func isFileWriteTool(name string) bool {
+	switch name {
+	case "Edit", "Write", "MultiEdit", "NotebookEdit":
+		return true
+	}
+	return mcpWriteTool.MatchString(name)
+}
+
+// tamperBash checks each command of a Bash line. Relative paths resolve
+// against the directory the command runs in, following cd and pushd, and
+// variables assigned earlier in the line are expanded. Operands of
+// modifying commands that stay unknown (command substitution, unset
+// variables, xargs input) fail closed, as does inline interpreter code
+// that names a protected file.
+func tamperBash(command string, protected []protectedPath, bypass []string, event *HookEvent) bool {
+	return tamperScript(command, event.ShellEnv(eventDir(event)), protected, bypass, event)
+}
+
+func tamperScript(script string, env *shell.Env, protected []protectedPath, bypass []string, event *HookEvent) bool {
+	for _, cmd := range shell.Parse(script) {
+		hit := tamperCommand(cmd, env, protected, bypass, event)
+		env.Step(cmd)
+		if hit {
+			return true
+		}
+	}
+	return false
+}
+
+func tamperCommand(cmd shell.Command, env *shell.Env, protected []protectedPath, bypass []string, event *HookEvent) bool {
+	deny := func(target, reason string, args ...any) bool {
+		event.capture("tamper_target", target)
+		event.capture("tamper_reason", fmt.Sprintf(reason, args...))
+		return true
+	}
+
+	for _, assignment := range cmd.Assignments {
+		if envAllowed(assignment.Name, bypass) {
+			return deny(assignment.Name, "command sets hook bypass variable %s", assignment.Name)
+		}
+	}
+
+	for _, redirect := range cmd.Redirects {
+		if !redirect.Writes() {
+			continue
+		}
+		paths, ok := env.Paths(cmd, redirect.Target)
+		if !ok {
+			return deny(redirect.Target, "redirection writes to %s, which is only known at run time and may be protected", redirect.Target)
+		}
+		if p, hit := matchAny(paths, protected, false); hit {
+			return deny(redirect.Target, "redirection writes to %s (%s)", p.path, p.reason)
+		}
+	}
+
+	name := cmd.Name()
+	if code, ok := inlineCode(cmd); ok {
+		dirs, _ := env.Dirs(cmd)
+		if isShell(name) {
+			// sh -c is already parsed; this is a script on standard input
+			dir := eventDir(event)
+			if len(dirs) > 0 {
+				dir = dirs[0]
+			}
+			if tamperScript(code, event.ShellEnv(dir), protected, bypass, event) {
+				return true
+			}
+		} else if p, hit := codeReferences(code, protected, dirs); hit {
+			return deny(p.path, "%s code refers to %s (%s); inline code is not parsed, so it is treated as modifying it", name, p.path, p.reason)
+		}
+	}
+
+	operands := commandOperands(cmd)
+	var targets []string
+	dir := ""        // Directory relative targets are in, "" for where cmd runs
+	modifies := true // Known to modify its targets: unknown targets fail closed
+	destructive := false
+	switch {
+	case mutatingCommands[name]:
+		targets, destructive = operands, true
+	case destinationCommands[name]:
+		if len(operands) > 0 {
+			targets = operands[len(operands)-1:]
+		}
+	case name == "sed" || name == "perl":
+		if hasInPlaceFlag(cmd.Args[1:]) {
+			targets = operands
+		}
+	case name == "dd":
+		for _, arg := range cmd.Args[1:] {
+			if strings.HasPrefix(arg, "of=") {
+				targets = append(targets, strings.TrimPrefix(arg, "of="))
+			}
+		}
+	case name == "find":
+		if containsAny(cmd.Args, "-delete", "-exec", "-execdir", "-ok") {
+			targets, destructive = operands, true
+		}
+	case name == "git":
+		inv, ok := parseGit(cmd.Args)
+		if ok && containsAny([]string{inv.sub}, "rm", "mv", "checkout", "restore", "clean") {
+			targets, _ = splitGitArgs(inv.args, gitValueFlags[inv.sub])
+			dir, destructive = inv.dir, true
+		}
+	case name == "hookctl":
+		if len(cmd.Args) > 2 && cmd.Args[1] == "config" && cmd.Args[2] == "lock" {
+			return deny(config.ManifestPath(), "hookctl config lock would re-pin the config integrity manifest; only the user may re-lock after reviewing the drift")
+		}
+		return false
+	case readOnlyCommands[name]:
+		// Reading protected files is fine
+		return false
+	default:
+		// Unknown programs handed a protected path may well modify it
+		targets, modifies = operands, false
+	}
+
+	if modifies && len(targets) == 0 && containsAny(cmd.Wrappers, "xargs") {
+		// Operands come from standard input: a here-string or heredoc can
+		// be checked, anything else can't
+		targets = stdinWords(cmd)
+		if targets == nil {
+			return deny(name, "xargs %s takes its operands from input, which may name protected paths", name)
+		}
+	}
+
+	for _, target := range targets {
+		paths, ok := resolveIn(env, cmd, dir, target)
+		if !ok {
+			if modifies {
+				return deny(target, "%s operand %s is only known at run time and may be a protected path", name, target)
+			}
+			continue
+		}
+		if p, hit := matchAny(paths, protected, destructive); hit {
+			return deny(target, "%s would modify %s (%s)", name, p.path, p.reason)
+		}
+	}
+	return false
+}
+
+// resolveIn resolves word inside dir (git -C dir), or where cmd runs
+func resolveIn(env *shell.Env, cmd shell.Command, dir, word string) ([]string, bool) {
+	if dir == "" {
+		return env.Paths(cmd, word)
+	}
+	word, ok := env.Expand(cmd, word)
+	if !ok {
+		return nil, false
+	}
+	if filepath.IsAbs(word) {
+		return []string{filepath.Clean(word)}, true
+	}
+	bases, ok := env.Paths(cmd, dir)
+	if !ok {
+		return nil, false
+	}
+	paths := make([]string, len(bases))
+	for i, base := range bases {
+		paths[i] = filepath.Join(base, word)
+	}
+	return paths, true
+}
+
+func matchAny(paths []string, protected []protectedPath, destructive bool) (protectedPath, bool) {
+	for _, target := range paths {
+		if p, hit := matchProtected(target, protected, destructive); hit {
+			return p, true
+		}
+	}
+	return protectedPath{}, false
+}
+
+// ShellEnv follows a Bash line from dir, with the hook's environment
+func (e *HookEvent) ShellEnv(dir string) *shell.Env {
+	environ := e.Env
+	if environ["HOME"] == "" {
+		environ = map[string]string{}
+		for name, value := range e.Env {
+			environ[name] = value
+		}
+		environ["HOME"], _ = os.UserHomeDir()
+	}
+	return shell.NewEnv(dir, environ)
+}
+
+// eventDir is the directory the tool call runs in
+func eventDir(event *HookEvent) string {
+	dir := event.Cwd
+	if dir == "" {
+		dir = event.Env["PWD"]
+	}
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	return dir
+}
+
+// Interpreters and the options that take a script on the command line
+var interpreterFlags = map[string][]string{
+	"python": {"-c"}, "perl": {"-e", "-E"}, "ruby": {"-e"}, "node": {"-e", "-p", "--eval", "--print"},
+	"nodejs": {"-e", "-p", "--eval", "--print"}, "php": {"-r"}, "lua": {"-e"}, "deno": {"eval"},
+	"bun": {"-e", "--eval"}, "osascript": {"-e"}, "Rscript": {"-e"}, "awk": {}, "gawk": {},
+	"sh": {}, "bash": {}, "zsh": {}, "dash": {}, "ksh": {},
+}
+
+// inlineCode returns the code an interpreter runs from its command line
+// (python3 -c, perl -pe, node -e) or from a heredoc or here-string. Shells
+// only count for standard input; sh -c is parsed as commands.
+func inlineCode(cmd shell.Command) (string, bool) {
+	name := cmd.Name()
+	if strings.HasPrefix(name, "python") {
+		name = "python"
+	}
+	flags, ok := interpreterFlags[name]
+	if !ok {
+		return "", false
+	}
+	if name == "awk" || name == "gawk" {
+		// The program is the first operand
+		if operands := commandOperands(cmd); len(operands) > 0 && !containsAny(cmd.Args, "-f") {
+			return operands[0], true
+		}
+	}
+	args := cmd.Args[1:]
+	for i, arg := range args {
+		if i+1 >= len(args) {
+			break
+		}
+		if containsAny(flags, arg) {
+			return args[i+1], true
+		}
+		// Bundled short options ending in the flag: perl -pe, -lne, -i -pe
+		for _, flag := range flags {
+			if len(flag) == 2 && flag[0] == '-' && len(arg) > 2 && arg[0] == '-' && arg[1] != '-' &&
+				arg[len(arg)-1] == flag[1] {
+				return args[i+1], true
+			}
+		}
+	}
+	for _, redirect := range cmd.Redirects {
+		switch redirect.Op {
+		case "<<":
+			return redirect.Body, true
+		case "<<<":
+			return redirect.Target, true
+		}
+	}
+	return "", false
+}
+
+func isShell(name string) bool {
+	switch name {
+	case "sh", "bash", "zsh", "dash", "ksh":
+		return true
+	}
+	return false
+}
+
+// stdinWords returns the words a here-string or heredoc feeds a command,
+// or nil when its input is something else
+func stdinWords(cmd shell.Command) []string {
+	for _, redirect := range cmd.Redirects {
+		switch redirect.Op {
+		case "<<":
+			return append([]string{}, strings.Fields(redirect.Body)...)
+		case "<<<":
+			return append([]string{}, strings.Fields(redirect.Target)...)
+		}
+	}
+	return nil
+}
+
+// codeReferences reports whether code names a protected path: absolutely,
+// under ~ or $HOME, or relative to a directory the code runs in
+func codeReferences(code string, protected []protectedPath, dirs []string) (protectedPath, bool) {
+	homeDir, _ := os.UserHomeDir()
+	for _, p := range protected {
+		forms := []string{p.path}
+		if homeDir != "" && isWithin(p.path, homeDir) {
+			rel, _ := filepath.Rel(homeDir, p.path)
+			forms = append(forms, "~/"+rel, "$HOME/"+rel, "${HOME}/"+rel, "/"+rel)
+		}
+		for _, dir := range dirs {
+			if isWithin(p.path, dir) {
+				rel, _ := filepath.Rel(dir, p.path)
+				forms = append(forms, rel)
+			}
+		}
+		for _, form := range forms {
+			if strings.Contains(code, form) {
+				return p, true
+			}
+		}
+	}
+	return protectedPath{}, false
+}
+
+// commandOperands returns a command's non-flag arguments. Values of
+// --flag=value options are included since they are often paths.
+func commandOperands(cmd shell.Command) []string {
+	var operands []string
+	if len(cmd.Args) < 2 {
+		return operands
+	}
+	for _, arg := range cmd.Args[1:] {
+		if strings.HasPrefix(arg, "-") {
+			if _, value, ok := strings.Cut(arg, "="); ok && value != "" {
+				operands = append(operands, value)
+			}
+			continue
+		}
+		operands = append(operands, arg)
+	}
+	return operands
+}
+
+func hasInPlaceFlag(args []string) bool {
+	for _, arg := range args {
+		if arg == "--in-place" || strings.HasPrefix(arg, "--in-place=") {
+			return true
+		}
+		if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.Contains(arg, "i") {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(list []string, values ...string) bool {
+	for _, item := range list {
+		for _, v := range values {
+			if item == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// protectedPaths collects everything the built-in tamper protection covers
+func protectedPaths(event *HookEvent, cfg *config.Config) []protectedPath {
+	var paths []protectedPath
+	add := func(p, reason string) {
+		if p != "" {
+			paths = append(paths, protectedPath{path: filepath.Clean(p), reason: reason})
+		}
+	}
+
+	// Every config layer the engine reads
+	if configPaths, err := config.SearchPaths(event.Env["CLAUDE_PROJECT_DIR"]); err == nil {
+		for _, dir := range configPaths {
+			for _, name := range config.ConfigFiles {
+				add(filepath.Join(dir, name), "hook engine config")
+			}
+			add(filepath.Join(dir, "scripts"), "hook engine scripts")
+		}
+	}
+	if cfg.ScriptsDir != "" {
+		add(cfg.ScriptsDir, "hook engine scripts")
+	}
+
+	// Session state, which can select the active profile
+	add(session.DefaultDir(), "hook session state")
+
+	// The integrity manifest pinning the reviewed config
+	add(config.ManifestPath(), "config integrity manifest")
+
+	// The decision log and its rotated files
+	if logger := audit.NewLogger(cfg.Settings.Audit); logger != nil {
+		add(logger.Path, "decision log")
+		for i := 1; i <= logger.MaxFiles; i++ {
+			add(fmt.Sprintf("%s.%d", logger.Path, i), "decision log")
+		}
+	}
+
+	// The engine's own binaries
+	if exe, err := os.Executable(); err == nil {
+		binDir := filepath.Dir(exe)
+		for _, name := range []string{"dispatcher", "hookctl", "workflow-guard-dispatcher"} {
+			add(filepath.Join(binDir, name), "hook engine binary")
+		}
+	}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		add(filepath.Join(homeDir, ".local", "bin", "workflow-guard-dispatcher"), "hook engine binary")
+		add(filepath.Join(homeDir, ".local", "bin", "hookctl"), "hook engine binary")
+	}
+
+	// The plugin's hook registration and engine binaries
+	if root := pluginRoot(event); root != "" {
+		add(filepath.Join(root, "hooks", "hooks.json"), "plugin hook registration")
+		add(filepath.Join(root, "engine", "bin"), "hook engine binary")
+	}
+
+	// Webhook deliveries waiting to be retried
+	add(webhook.DefaultSpoolDir(), "webhook spool")
+
+	// The project's secret-detection allowlist
+	add(secretsAllowlistPath(nil, event), "secret-detection allowlist")
+
+	for _, p := range cfg.Settings.Protect.Paths {
+		add(expandHome(p), "protected by settings")
+	}
+	return paths
+}
+
+// pluginRoot locates the workflow-guard plugin, from CLAUDE_PLUGIN_ROOT or
+// from the dispatcher's own location in <root>/engine/bin
+func pluginRoot(event *HookEvent) string {
+	if root := event.Env["CLAUDE_PLUGIN_ROOT"]; root != "" {
+		return root
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	binDir := filepath.Dir(exe)
+	if filepath.Base(binDir) != "bin" || filepath.Base(filepath.Dir(binDir)) != "engine" {
+		return ""
+	}
+	root := filepath.Dir(filepath.Dir(binDir))
+	if _, err := os.Stat(filepath.Join(root, "hooks", "hooks.json")); err != nil {
+		return ""
+	}
+	return root
+}
+
+func bypassEnv(cfg *config.Config) []string {
+	return append(append([]string{}, BypassEnv...), cfg.Settings.Protect.Env...)
+}
+
+// matchProtected reports whether target is, or is inside, a protected path.
+// Destructive operations (rm -r, mv, chmod -R) also match ancestors of a
+// protected path, since removing a directory removes what it contains.
+func matchProtected(target string, protected []protectedPath, destructive bool) (protectedPath, bool) {
+	if target == "" {
+		return protectedPath{}, false
+	}
+	candidates := []string{target}
+	if resolved := resolveSymlinks(target); resolved != target {
+		candidates = append(candidates, resolved)
+	}
+
+	for _, p := range protected {
+		protectedCandidates := []string{p.path}
+		if resolved := resolveSymlinks(p.path); resolved != p.path {
+			protectedCandidates = append(protectedCandidates, resolved)
+		}
+		for _, t := range candidates {
+			for _, pp := range protectedCandidates {
+				if t == pp || isWithin(t, pp) || (destructive && isWithin(pp, t)) {
+					return p, true
+				}
+				// Globs in the target (rm ~/.claude/*.yaml)
+				if strings.ContainsAny(t, "*?[") {
+					if matched, _ := path.Match(t, pp); matched {
+						return p, true
+					}
+				}
+			}
+		}
+	}
+	return protectedPath{}, false
+}
+
+func isWithin(p, dir string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != "." && !strings.HasPrefix(rel, "..")
+}
+
+// resolvePath turns a tool argument into an absolute, clean path
+func resolvePath(p string, event *HookEvent) string {
+	p = expandHome(p)
+	p = strings.NewReplacer("${HOME}", os.Getenv("HOME"), "$HOME", os.Getenv("HOME")).Replace(p)
+	if !filepath.IsAbs(p) {
+		base := event.Cwd
+		if base == "" {
+			base = event.Env["PWD"]
+		}
+		if base == "" {
+			base, _ = os.Getwd()
+		}
+		p = filepath.Join(base, p)
+	}
+	return filepath.Clean(p)
+}
+
+func expandHome(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(homeDir, strings.TrimPrefix(p, "~"))
+		}
+	}
+	return p
+}
+
+// resolveSymlinks resolves the longest existing prefix of a path, so links
+// pointing at protected files are caught even if the leaf doesn't exist yet
+func resolveSymlinks(p string) string {
+	rest := ""
+	current := p
+	for {
+		if resolved, err := filepath.EvalSymlinks(current); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return p
+		}
+		rest = filepath.Join(filepath.Base(current), rest)
+		current = parent
+	}
+}
@@ -0,0 +1,108 @@
+package conditions
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+)
+
+// bashEvent builds a Bash PreToolUse event run from cwd with HOME=home
+func bashEvent(t *testing.T, command, cwd, home string) *HookEvent {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{
+		"hook_event_name": "PreToolUse",
+		"tool_name":       "Bash",
+		"tool_input":      map[string]any{"command": command},
+		"cwd":             cwd,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := NewEvent(data, map[string]string{"HOME": home, "PWD": cwd}, &config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestTamperBash(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	hooks := filepath.Join(home, ".claude-hooks")
+	if err := os.MkdirAll(hooks, 0755); err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(home, "project")
+
+	tests := []struct {
+		name    string
+		command string
+		cwd     string
+		want    bool
+	}{
+		{"literal path", "rm ~/.claude-hooks/rules.yaml", project, true},
+		{"cd then relative", "cd ~/.claude-hooks && rm rules.yaml", project, true},
+		{"pushd then relative", "pushd ~/.claude-hooks; rm rules.yaml", project, true},
+		{"cd twice", "cd ~ && cd .claude-hooks && rm rules.yaml", project, true},
+		{"variable", "D=~/.claude-hooks; rm $D/rules.yaml", project, true},
+		{"braced variable", "export D=$HOME/.claude-hooks; rm ${D}/rules.yaml", project, true},
+		{"git -C", "git -C ~/.claude-hooks checkout rules.yaml", project, true},
+		{"git -C relative", "cd ~ && git -C .claude-hooks restore .", project, true},
+		{"xargs here-string", "xargs rm <<< ~/.claude-hooks/rules.yaml", project, true},
+		{"xargs from a pipe", "find . -name '*.o' | xargs rm", project, true},
+		{"python -c", "python3 -c \"open('" + hooks + "/rules.yaml','w')\"", project, true},
+		{"python -c under ~", "python3 -c \"open(os.path.expanduser('~/.claude-hooks/rules.yaml'),'w')\"", project, true},
+		{"python -c after cd", "cd ~/.claude-hooks && python3 -c \"open('rules.yaml','w')\"", project, true},
+		{"python heredoc", "python3 <<'EOF'\nopen('" + hooks + "/rules.yaml', 'w')\nEOF", project, true},
+		{"perl -e", "perl -e 'unlink \"$ENV{HOME}/.claude-hooks/rules.yaml\"'", project, true},
+		{"bash heredoc", "bash <<'EOF'\ncd ~/.claude-hooks\nrm rules.yaml\nEOF", project, true},
+		{"sh -c with cd", "sh -c 'cd ~/.claude-hooks && rm rules.yaml'", project, true},
+		{"command substitution operand", "rm \"$(echo rules.yaml)\"", project, true},
+		{"backtick operand", "rm `echo rules.yaml`", project, true},
+		{"unset variable operand", "rm -rf $NOT_SET/build", project, true},
+		{"redirect", "echo '' > ~/.claude-hooks/rules.yaml", project, true},
+		{"redirect after cd", "cd ~/.claude-hooks; echo '' > rules.yaml", project, true},
+		{"redirect to unknown path", "echo x > \"$(mktemp)\"", project, true},
+		{"sed -i relative", "sed -i 's/deny/allow/' rules.yaml", hooks, true},
+		{"perl -i", "perl -i -pe 's/deny/allow/' ~/.claude-hooks/rules.yaml", project, true},
+		{"cd that may fail", "cd /nonexistent; rm rules.yaml", hooks, true},
+		{"cd failing in an && chain", "cd /tmp && true; rm rules.yaml", hooks, true},
+		{"cd in a subshell", "(cd /tmp && true); rm rules.yaml", hooks, true},
+		{"cd in a pipeline", "cd /tmp | true && rm rules.yaml", hooks, true},
+		{"cd -", "cd - && rm rules.yaml", project, true},
+		{"popd", "pushd /tmp && popd && rm rules.yaml", hooks, true},
+		{"bypass variable", "WORKFLOW_GUARD_ON_ERROR=allow make", project, true},
+		{"hookctl config lock", "hookctl config lock", project, true},
+		{"hookctl config lock by path", "./engine/bin/hookctl config lock --on-drift deny", project, true},
+
+		{"cd elsewhere", "cd /tmp && rm rules.yaml", hooks, false},
+		{"popd back", "pushd ~/.claude-hooks && popd && rm rules.yaml", project, false},
+		{"subshell cd stays inside", "(cd ~/.claude-hooks && cat rules.yaml); rm notes.txt", project, false},
+		{"read config", "cat ~/.claude-hooks/rules.yaml", project, false},
+		{"project file", "rm -rf build/out.o", project, false},
+		{"sed -i project file", "sed -i 's/a/b/' notes.txt", project, false},
+		{"python -c elsewhere", "python3 -c 'print(1)'", project, false},
+		{"python reading project rules", "python3 -c \"import yaml; yaml.safe_load(open('engine/rules.yaml'))\"", project, false},
+		{"substitution for unknown program", "go test $(go list ./...)", project, false},
+		{"known variable", "rm -rf $HOME/tmp/cache", project, false},
+		{"hookctl config verify", "hookctl config verify", project, false},
+		{"hookctl test", "hookctl test event.json", project, false},
+		{"redirect to /dev/null", "make > /dev/null 2>&1", project, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := bashEvent(t, tt.command, tt.cwd, home)
+			got, err := evaluateTamper(&config.Condition{}, event, &config.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("tamper(%q) = %v, want %v (target %v, reason %v)",
+					tt.command, got, tt.want, event.Captures["tamper_target"], event.Captures["tamper_reason"])
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package conditions
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/cycle"
+)
+
+// taskDescription matches a Task description of the form
+// [project:branch] agent - description
+var taskDescription = regexp.MustCompile(`^\s*\[([^\]:]+):([^\]]+)\]\s+(\S+)\s+-\s+(.*\S)\s*$`)
+
+// evaluateTaskDescription matches a Task call whose description is not
+// "[project:branch] agent - description" for where and by whom the work
+// happens: the project (git.project) and branch of the working directory,
+// and the dispatched agent (TaskAgent), which must be listed under
+// agents.roles.
+// Outside a repository only the agent is checked, and on a detached HEAD
+// the branch isn't. It records task_description, task_problems,
+// task_expected (the description corrected) and task_agents (the roster).
+func evaluateTaskDescription(cond *config.Condition, event *HookEvent, cfg *config.Config) (bool, error) {
+	if event.ToolName != "Task" {
+		return false, nil
+	}
+	description, _ := event.ToolInput["description"].(string)
+	agent, err := event.TaskAgent()
+	if err != nil {
+		return false, err
+	}
+	project, _ := event.Field("git.project").(string)
+	branch, _ := event.Field("git.branch").(string)
+	root, _ := event.Field("git.root").(string)
+
+	var problems []string
+	summary := strings.TrimSpace(description)
+	m := taskDescription.FindStringSubmatch(description)
+	if m == nil {
+		problems = append(problems, "it is not in the form [project:branch] agent - description")
+	} else {
+		summary = m[4]
+		if root != "" && m[1] != project {
+			problems = append(problems, fmt.Sprintf("project %s is not this repository's (%s)", m[1], project))
+		}
+		if root != "" && branch != "" && m[2] != branch {
+			problems = append(problems, fmt.Sprintf("branch %s is not the current branch (%s)", m[2], branch))
+		}
+		if agent == "" {
+			agent = m[3]
+		} else if cycle.AgentName(m[3]) != agent {
+			problems = append(problems, fmt.Sprintf("agent %s is not the dispatched agent (%s)", m[3], agent))
+		}
+	}
+	if _, known := cfg.Settings.Agents.Roles[agent]; agent != "" && !known {
+		problems = append(problems, fmt.Sprintf("%s is not a known agent (agents.roles in settings.yaml)", agent))
+	}
+	if len(problems) == 0 {
+		return false, nil
+	}
+
+	agents := make([]string, 0, len(cfg.Settings.Agents.Roles))
+	for name := range cfg.Settings.Agents.Roles {
+		agents = append(agents, name)
+	}
+	sort.Strings(agents)
+	if root == "" {
+		project = filepath.Base(event.WorkDir())
+	}
+	if branch == "" {
+		branch = "<branch>"
+	}
+	if _, known := cfg.Settings.Agents.Roles[agent]; !known {
+		agent = "<agent>"
+	}
+	if summary == "" {
+		summary = "<what the agent does>"
+	}
+	event.capture("task_description", description)
+	event.capture("task_problems", problems)
+	event.capture("task_expected", fmt.Sprintf("[%s:%s] %s - %s", project, branch, agent, summary))
+	event.capture("task_agents", agents)
+	return true, nil
+}
@@ -0,0 +1,117 @@
+package conditions
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+)
+
+// agentConfig has the scaffold's markers and a few of its roles
+func agentConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Settings.Agents = config.AgentSettings{
+		Markers: []string{`working as the ([A-Za-z0-9_-]+) agent`, `You are (Explore|Plan)\b`},
+		Roles: map[string]config.AgentRole{
+			"code-developer": {Cycle: "code", Stage: "creator"},
+			"code-reviewer":  {Cycle: "code", Stage: "critic"},
+			"Explore":        {},
+		},
+	}
+	return cfg
+}
+
+// taskEvent builds a Task PreToolUse event in a repository on branch
+func taskEvent(t *testing.T, cfg *config.Config, input map[string]any, root, branch string) *HookEvent {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{
+		"hook_event_name": "PreToolUse",
+		"tool_name":       "Task",
+		"tool_input":      input,
+		"cwd":             "/work/wg",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := NewEvent(data, map[string]string{}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event.Raw["git"] = map[string]any{"root": root, "project": "wg", "branch": branch}
+	return event
+}
+
+func TestTaskAgent(t *testing.T) {
+	tests := []struct {
+		subagent string
+		prompt   string
+		want     string
+	}{
+		{"general-purpose", "You are working as the code-developer agent. Fix it.", "code-developer"},
+		{"Explore", "You are Explore, an investigation agent.", "Explore"},
+		{"qc-router:code-reviewer", "Review the change.", "code-reviewer"},
+		{"general-purpose", "Fix it.", "general-purpose"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		event := taskEvent(t, agentConfig(), map[string]any{"subagent_type": tt.subagent, "prompt": tt.prompt}, "/work/wg", "feature")
+		got, err := event.TaskAgent()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("TaskAgent(%q, %q) = %q, want %q", tt.subagent, tt.prompt, got, tt.want)
+		}
+	}
+}
+
+func TestTaskDescription(t *testing.T) {
+	const developer = "You are working as the code-developer agent ..."
+	tests := []struct {
+		name        string
+		subagent    string
+		prompt      string
+		description string
+		root        string
+		want        string // A problem the description must report, "" when valid
+	}{
+		{"general-purpose named in the prompt", "general-purpose", developer, "[wg:feature] code-developer - fix the parser", "/work/wg", ""},
+		{"plugin agent", "qc-router:code-reviewer", "Review it.", "[wg:feature] code-reviewer - review the parser", "/work/wg", ""},
+		{"plugin agent in full", "qc-router:code-reviewer", "Review it.", "[wg:feature] qc-router:code-reviewer - review", "/work/wg", ""},
+		{"Explore", "Explore", "You are Explore, an investigation agent.", "[wg:feature] Explore - find the parser", "/work/wg", ""},
+		{"outside a repository", "Explore", "", "[elsewhere:main] Explore - look around", "", ""},
+		{"subagent_type instead of the agent", "general-purpose", developer, "[wg:feature] general-purpose - fix", "/work/wg", "agent general-purpose is not the dispatched agent (code-developer)"},
+		{"unknown agent", "general-purpose", "Fix it.", "[wg:feature] general-purpose - fix", "/work/wg", "general-purpose is not a known agent"},
+		{"wrong branch", "general-purpose", developer, "[wg:main] code-developer - fix", "/work/wg", "branch main is not the current branch (feature)"},
+		{"wrong project", "general-purpose", developer, "[other:feature] code-developer - fix", "/work/wg", "project other is not this repository's (wg)"},
+		{"free text", "general-purpose", developer, "fix the parser", "/work/wg", "not in the form"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := agentConfig()
+			input := map[string]any{"subagent_type": tt.subagent, "prompt": tt.prompt, "description": tt.description}
+			event := taskEvent(t, cfg, input, tt.root, "feature")
+			matched, err := evaluateTaskDescription(&config.Condition{}, event, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			problems, _ := event.Captures["task_problems"].([]string)
+			if matched != (tt.want != "") {
+				t.Fatalf("matched = %v, problems %q", matched, problems)
+			}
+			if tt.want != "" && !strings.Contains(strings.Join(problems, "\n"), tt.want) {
+				t.Errorf("problems = %q, want one containing %q", problems, tt.want)
+			}
+		})
+	}
+
+	// The correction names the agent the prompt dispatches
+	cfg := agentConfig()
+	input := map[string]any{"subagent_type": "general-purpose", "prompt": developer, "description": "fix the parser"}
+	event := taskEvent(t, cfg, input, "/work/wg", "feature")
+	evaluateTaskDescription(&config.Condition{}, event, cfg)
+	if got := event.Captures["task_expected"]; got != "[wg:feature] code-developer - fix the parser" {
+		t.Errorf("task_expected = %q", got)
+	}
+}
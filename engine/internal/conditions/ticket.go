@@ -0,0 +1,180 @@
+package conditions
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/shell"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/ticket"
+)
+
+// prCreateTool matches MCP tools that open a pull request, such as
+// mcp__github__create_pull_request
+var prCreateTool = regexp.MustCompile(`^mcp__.*create_pull_request$`)
+
+// ticketFields describes the ticket for the current branch as ticket.*:
+// found, id, session_id, sequence, status, cycle_type, worktree_path,
+// title, parent_ticket, location (queue, active or completed), path, valid
+// and problems. Tickets are looked up in the working tree's tickets/
+// directory, so a worktree sees its own copy. Without a ticket only found
+// (false) and session_id are set.
+func ticketFields(e *HookEvent) (any, error) {
+	// Read git.* directly: going through Field would make the providers
+	// table refer to itself
+	git, ok := e.Raw["git"].(map[string]any)
+	if !ok {
+		value, _ := gitFields(e)
+		git, _ = value.(map[string]any)
+		if e.Raw != nil {
+			e.Raw["git"] = git
+		}
+	}
+	root, _ := git["root"].(string)
+	branch, _ := git["branch"].(string)
+	if root == "" || branch == "" {
+		return map[string]any{"found": false}, nil
+	}
+	t, err := ticket.ForBranch(root, branch)
+	if err != nil {
+		return map[string]any{"found": false}, &EvalError{Kind: ErrTicket, Err: err}
+	}
+	if t == nil {
+		return map[string]any{"found": false, "session_id": ticket.SessionForBranch(branch)}, nil
+	}
+	return t.Fields(), nil
+}
+
+// evaluateTicketSchema matches a Write of a ticket file (TICKET-*.md under
+// tickets/queue, active or completed) whose content is not a valid ticket:
+// missing frontmatter keys, an unknown status or cycle_type, or a
+// ticket_id, session_id or sequence that disagrees with the filename. It
+// records ticket_file, ticket_problems (one entry per violation) and
+// ticket_problem_count.
+func evaluateTicketSchema(cond *config.Condition, event *HookEvent, cfg *config.Config) (bool, error) {
+	if event.ToolName != "Write" {
+		return false, nil
+	}
+	filePath, _ := event.ToolInput["file_path"].(string)
+	content, _ := event.ToolInput["content"].(string)
+	if filePath == "" || !ticket.IsTicketFile(filepath.Base(filePath)) {
+		return false, nil
+	}
+	t := ticket.Parse(filePath, []byte(content))
+	if t.Location == "" {
+		return false, nil
+	}
+	problems := t.Validate()
+	if len(problems) == 0 {
+		return false, nil
+	}
+	event.capture("ticket_file", filePath)
+	event.capture("ticket_problems", problems)
+	event.capture("ticket_problem_count", len(problems))
+	return true, nil
+}
+
+// evaluateTicketCompletion matches opening a pull request (gh pr create or
+// gh pr new anywhere in a Bash command, or an MCP create_pull_request
+// tool) from a branch whose ticket is still in tickets/active/. The branch
+// is the --head of the request, else the current one; protected branches
+// and branches without a ticket are left alone. It records pr_branch,
+// pr_ticket, pr_ticket_path, pr_completed_path and pr_worktree (where to
+// run hookctl ticket complete).
+func evaluateTicketCompletion(cond *config.Condition, event *HookEvent, cfg *config.Config) (bool, error) {
+	head, ok := pullRequestHead(event)
+	if !ok {
+		return false, nil
+	}
+	root, _ := event.Field("git.root").(string)
+	current, _ := event.Field("git.branch").(string)
+	branch := head
+	if branch == "" {
+		branch = current
+	}
+	if root == "" || branch == "" || ticket.IsProtectedBranch(branch) {
+		return false, nil
+	}
+
+	t, err := ticket.ForBranch(root, branch)
+	if err != nil {
+		return false, &EvalError{Kind: ErrTicket, Err: err}
+	}
+	if t == nil || t.Location != ticket.Active {
+		return false, nil
+	}
+	worktree := root
+	if branch != current && t.WorktreePath != "" {
+		worktree = t.WorktreePath
+	}
+	rel, _ := filepath.Rel(root, t.Path)
+	event.capture("pr_branch", branch)
+	event.capture("pr_ticket", strings.TrimSuffix(filepath.Base(t.Path), ".md"))
+	event.capture("pr_ticket_path", rel)
+	event.capture("pr_completed_path", filepath.Join("tickets", ticket.Completed, t.Dir, filepath.Base(t.Path)))
+	event.capture("pr_worktree", worktree)
+	return true, nil
+}
+
+// pullRequestHead reports whether the event opens a pull request and the
+// head branch it names ("" for the current branch)
+func pullRequestHead(event *HookEvent) (string, bool) {
+	if prCreateTool.MatchString(event.ToolName) {
+		head, _ := event.ToolInput["head"].(string)
+		return stripOwner(head), true
+	}
+	if event.ToolName != "Bash" {
+		return "", false
+	}
+	command, _ := event.ToolInput["command"].(string)
+	for _, cmd := range shell.Parse(command) {
+		if head, ok := ghPRCreate(cmd.Args); ok {
+			return head, true
+		}
+	}
+	return "", false
+}
+
+// ghPRCreate recognises gh [-R repo] pr create|new and returns its
+// --head/-H branch
+func ghPRCreate(args []string) (string, bool) {
+	if len(args) < 3 || filepath.Base(args[0]) != "gh" {
+		return "", false
+	}
+	var words []string
+	i := 1
+	for ; i < len(args) && len(words) < 2; i++ {
+		switch arg := args[i]; {
+		case arg == "-R" || arg == "--repo":
+			i++
+		case strings.HasPrefix(arg, "-"):
+		default:
+			words = append(words, arg)
+		}
+	}
+	if len(words) < 2 || words[0] != "pr" || (words[1] != "create" && words[1] != "new") {
+		return "", false
+	}
+	for ; i < len(args); i++ {
+		switch arg := args[i]; {
+		case (arg == "-H" || arg == "--head") && i+1 < len(args):
+			return stripOwner(args[i+1]), true
+		case strings.HasPrefix(arg, "--head="):
+			return stripOwner(strings.TrimPrefix(arg, "--head=")), true
+		case strings.HasPrefix(arg, "-H") && !strings.HasPrefix(arg, "--"):
+			return stripOwner(arg[2:]), true
+		case arg == "--":
+			return "", true
+		}
+	}
+	return "", true
+}
+
+// stripOwner turns a cross-repository head (owner:branch) into the branch
+func stripOwner(head string) string {
+	if _, branch, ok := strings.Cut(head, ":"); ok {
+		return branch
+	}
+	return head
+}
@@ -0,0 +1,100 @@
+package conditions
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// transcriptEntry is the part of a transcript line the provider reads
+type transcriptEntry struct {
+	Type    string `json:"type"`
+	Message struct {
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+type transcriptBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+	Name string `json:"name"`
+}
+
+// transcriptFields summarises the session transcript as transcript.*:
+// messages (user and assistant entries), last_user and last_assistant
+// (text of the latest of each), tool_uses and last_tool. A transcript that
+// doesn't exist yet is empty; one that can't be read is an ErrTranscript.
+func transcriptFields(e *HookEvent) (any, error) {
+	fields := map[string]any{}
+	if e.TranscriptPath == "" {
+		return fields, nil
+	}
+	fields["path"] = e.TranscriptPath
+
+	f, err := os.Open(expandHome(e.TranscriptPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return fields, nil
+	}
+	if err != nil {
+		return fields, &EvalError{Kind: ErrTranscript, Err: err}
+	}
+	defer f.Close()
+
+	messages, toolUses := 0, 0
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			var entry transcriptEntry
+			if json.Unmarshal(line, &entry) == nil && (entry.Type == "user" || entry.Type == "assistant") {
+				text, tools := transcriptContent(entry.Message.Content)
+				toolUses += len(tools)
+				if len(tools) > 0 {
+					fields["last_tool"] = tools[len(tools)-1]
+				}
+				// Tool results arrive as user entries without text
+				if text != "" || entry.Type == "assistant" {
+					messages++
+				}
+				if text != "" {
+					fields["last_"+entry.Type] = text
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fields, &EvalError{Kind: ErrTranscript, Err: err}
+		}
+	}
+	fields["messages"] = messages
+	fields["tool_uses"] = toolUses
+	return fields, nil
+}
+
+// transcriptContent returns the text of a message and the tools it used.
+// Content is either a string or a list of typed blocks.
+func transcriptContent(raw json.RawMessage) (string, []string) {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return text, nil
+	}
+	var blocks []transcriptBlock
+	if json.Unmarshal(raw, &blocks) != nil {
+		return "", nil
+	}
+	var texts, tools []string
+	for _, block := range blocks {
+		switch block.Type {
+		case "text":
+			texts = append(texts, block.Text)
+		case "tool_use":
+			tools = append(tools, block.Name)
+		}
+	}
+	return strings.Join(texts, "\n"), tools
+}
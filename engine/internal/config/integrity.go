@@ -0,0 +1,255 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Drift policies recorded in the manifest
+const (
+	// OnDriftRefuse skips every layer whose files no longer match
+	OnDriftRefuse = "refuse"
+	// OnDriftDeny blocks every tool call until the drift is resolved
+	OnDriftDeny = "deny"
+	// OnDriftAsk asks the user to confirm every tool call instead
+	OnDriftAsk = "ask"
+)
+
+// Manifest pins the SHA-256 of every config file and script that was
+// reviewed. The drift policy lives in the manifest rather than in
+// settings.yaml, so changing a layer cannot also relax the policy.
+type Manifest struct {
+	Version int               `json:"version"`
+	Created time.Time         `json:"created"`
+	OnDrift string            `json:"on_drift"`
+	Layers  []string          `json:"layers"`
+	Files   map[string]string `json:"files"`
+}
+
+// Drift describes one file that differs from the manifest
+type Drift struct {
+	Layer string `json:"layer"`
+	File  string `json:"file"`
+	Kind  string `json:"kind"` // modified, missing, added, unpinned
+}
+
+// IntegrityStatus reports the outcome of manifest verification at load time
+type IntegrityStatus struct {
+	Manifest string   // Manifest path, "" when no manifest is in use
+	OnDrift  string   // Policy from the manifest
+	Drift    []Drift  // Every difference found
+	Refused  []string // Layers skipped because of drift
+	// FailClosed is the decision (deny or ask) every tool call receives
+	// when the policy is fail-closed and drift was found; prompts are
+	// denied and other events pass
+	FailClosed string
+}
+
+// ManifestPath returns the integrity manifest location.
+// WORKFLOW_GUARD_MANIFEST overrides ~/.claude/workflow-guard/manifest.json.
+func ManifestPath() string {
+	if path := os.Getenv("WORKFLOW_GUARD_MANIFEST"); path != "" {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".claude", "workflow-guard", "manifest.json")
+}
+
+// BuildManifest hashes every config file and script under the given paths
+func BuildManifest(configPaths []string, onDrift string) (*Manifest, error) {
+	switch onDrift {
+	case OnDriftRefuse, OnDriftDeny, OnDriftAsk:
+	default:
+		return nil, fmt.Errorf("unknown drift policy %q (want refuse, deny or ask)", onDrift)
+	}
+
+	manifest := &Manifest{
+		Version: 1,
+		Created: time.Now().UTC(),
+		OnDrift: onDrift,
+		Files:   make(map[string]string),
+	}
+	for _, layer := range configPaths {
+		files := LayerFiles(layer)
+		if len(files) == 0 {
+			continue
+		}
+		manifest.Layers = append(manifest.Layers, layer)
+		for _, file := range files {
+			sum, err := hashFile(file)
+			if err != nil {
+				return nil, err
+			}
+			manifest.Files[file] = sum
+		}
+	}
+	return manifest, nil
+}
+
+// ReadManifest loads a manifest. A missing file returns (nil, nil).
+func ReadManifest(path string) (*Manifest, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("corrupt manifest %s: %w", path, err)
+	}
+	if manifest.Files == nil {
+		manifest.Files = make(map[string]string)
+	}
+	return &manifest, nil
+}
+
+// WriteManifest saves a manifest, readable only by its owner
+func WriteManifest(path string, manifest *Manifest) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// Verify compares the files under the given paths with the manifest.
+// Layers that contain config but were never locked are reported as
+// unpinned, since nothing vouches for them.
+func (m *Manifest) Verify(configPaths []string) []Drift {
+	var drift []Drift
+	for _, layer := range configPaths {
+		files := LayerFiles(layer)
+		if !contains(m.Layers, layer) {
+			for _, file := range files {
+				drift = append(drift, Drift{Layer: layer, File: file, Kind: "unpinned"})
+			}
+			continue
+		}
+
+		present := make(map[string]bool)
+		for _, file := range files {
+			present[file] = true
+			want, pinned := m.Files[file]
+			if !pinned {
+				drift = append(drift, Drift{Layer: layer, File: file, Kind: "added"})
+				continue
+			}
+			if got, err := hashFile(file); err != nil || got != want {
+				drift = append(drift, Drift{Layer: layer, File: file, Kind: "modified"})
+			}
+		}
+
+		for file := range m.Files {
+			if filepath.Dir(file) != layer && !isUnder(file, filepath.Join(layer, "scripts")) {
+				continue
+			}
+			if !present[file] {
+				drift = append(drift, Drift{Layer: layer, File: file, Kind: "missing"})
+			}
+		}
+	}
+
+	sort.Slice(drift, func(i, j int) bool { return drift[i].File < drift[j].File })
+	return drift
+}
+
+// LayerFiles lists the config files and scripts of one config directory
+func LayerFiles(layer string) []string {
+	var files []string
+	for _, name := range ConfigFiles {
+		path := filepath.Join(layer, name)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			files = append(files, path)
+		}
+	}
+
+	scriptsDir := filepath.Join(layer, "scripts")
+	filepath.Walk(scriptsDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	})
+
+	sort.Strings(files)
+	return files
+}
+
+// verifyIntegrity checks the manifest and decides which layers may load
+func verifyIntegrity(configPaths []string) (IntegrityStatus, []string) {
+	status := IntegrityStatus{}
+	path := ManifestPath()
+
+	manifest, err := ReadManifest(path)
+	if err != nil {
+		// A manifest we can't read vouches for nothing
+		status.Manifest = path
+		status.OnDrift = OnDriftDeny
+		status.FailClosed = OnDriftDeny
+		status.Drift = []Drift{{File: path, Kind: "unreadable"}}
+		return status, nil
+	}
+	if manifest == nil {
+		return status, configPaths
+	}
+
+	status.Manifest = path
+	status.OnDrift = manifest.OnDrift
+	status.Drift = manifest.Verify(configPaths)
+
+	drifted := make(map[string]bool)
+	for _, d := range status.Drift {
+		drifted[d.Layer] = true
+	}
+
+	allowed := []string{}
+	for _, layer := range configPaths {
+		if drifted[layer] {
+			status.Refused = append(status.Refused, layer)
+			continue
+		}
+		allowed = append(allowed, layer)
+	}
+
+	if len(status.Drift) > 0 && (manifest.OnDrift == OnDriftDeny || manifest.OnDrift == OnDriftAsk) {
+		status.FailClosed = manifest.OnDrift
+	}
+	return status, allowed
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func isUnder(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && len(rel) > 0 && rel[0] != '.'
+}
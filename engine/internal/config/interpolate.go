@@ -0,0 +1,73 @@
+package config
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// interpolateNode expands environment references in every string scalar of
+// a YAML tree. Keys are left alone so a variable can't rename a rule.
+func interpolateNode(node *yaml.Node, lookup func(string) (string, bool)) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			interpolateNode(child, lookup)
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			interpolateNode(node.Content[i], lookup)
+		}
+	case yaml.ScalarNode:
+		if node.Tag != "!!str" && node.Tag != "" {
+			return
+		}
+		expanded := Interpolate(node.Value, lookup)
+		if expanded != node.Value {
+			node.Value = expanded
+			// Let plain scalars re-resolve, so ${PRIORITY:-100} is an int
+			if node.Style == 0 {
+				node.Tag = ""
+			}
+		}
+	}
+}
+
+// Interpolate expands ${VAR} and ${VAR:-default} references.
+// The default applies when VAR is unset or empty; "$${" escapes a literal
+// "${". Unterminated references are left as written.
+func Interpolate(value string, lookup func(string) (string, bool)) string {
+	if !strings.Contains(value, "${") {
+		return value
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); {
+		if strings.HasPrefix(value[i:], "$${") {
+			b.WriteString("${")
+			i += 3
+			continue
+		}
+		if !strings.HasPrefix(value[i:], "${") {
+			b.WriteByte(value[i])
+			i++
+			continue
+		}
+
+		end := strings.IndexByte(value[i:], '}')
+		if end < 0 {
+			b.WriteString(value[i:])
+			break
+		}
+		expr := value[i+2 : i+end]
+		i += end + 1
+
+		name, fallback, hasDefault := strings.Cut(expr, ":-")
+		if v, ok := lookup(name); ok && (v != "" || !hasDefault) {
+			b.WriteString(v)
+		} else if hasDefault {
+			b.WriteString(fallback)
+		}
+	}
+	return b.String()
+}
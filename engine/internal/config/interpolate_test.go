@@ -0,0 +1,118 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	vars := map[string]string{"NAME": "guard", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"plain", "plain"},
+		{"${NAME}", "guard"},
+		{"a-${NAME}-b", "a-guard-b"},
+		{"${NAME}${NAME}", "guardguard"},
+		{"${UNSET}", ""},
+		{"${EMPTY}", ""},
+		{"${UNSET:-fallback}", "fallback"},
+		{"${EMPTY:-fallback}", "fallback"},
+		{"${NAME:-fallback}", "guard"},
+		{"${UNSET:-}", ""},
+		{"${UNSET:-a:-b}", "a:-b"},
+		{"$${NAME}", "${NAME}"},
+		{"$NAME", "$NAME"},
+		{"${NAME", "${NAME"},
+	}
+	for _, tt := range tests {
+		if got := Interpolate(tt.value, lookup); got != tt.want {
+			t.Errorf("Interpolate(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigInterpolates(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("WG_TEST_PRIORITY", "")
+	t.Setenv("WG_TEST_MESSAGE", "from the environment")
+	t.Setenv("WG_TEST_ID", "renamed")
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "rules.yaml"), `
+rules:
+  - id: interpolated
+    enabled: true
+    priority: ${WG_TEST_PRIORITY:-150}
+    trigger:
+      event: PreToolUse
+    actions:
+      - type: decision
+        decision: deny
+        message: "${WG_TEST_MESSAGE}"
+      - type: decision
+        decision: deny
+        message: "$${WG_TEST_MESSAGE}"
+`)
+
+	cfg, err := LoadConfigFrom([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Rules) != 1 {
+		t.Fatalf("loaded %d rules, want 1", len(cfg.Rules))
+	}
+	rule := cfg.Rules[0]
+	if rule.Priority != 150 {
+		t.Errorf("priority = %d, want the default 150 for an empty variable", rule.Priority)
+	}
+	if got := rule.Actions[0].Message; got != "from the environment" {
+		t.Errorf("message = %q, want the variable's value", got)
+	}
+	if got := rule.Actions[1].Message; got != "${WG_TEST_MESSAGE}" {
+		t.Errorf("escaped message = %q, want it literal", got)
+	}
+
+	// Keys are not expanded, so a variable can't rename anything
+	var values map[string]string
+	if err := decodeYAML([]byte("${WG_TEST_ID}: ${WG_TEST_ID}\n"), &values); err != nil {
+		t.Fatal(err)
+	}
+	if values["${WG_TEST_ID}"] != "renamed" {
+		t.Errorf("decoded %v, want the key as written and the value expanded", values)
+	}
+}
+
+func TestLoadConfigMergesEnvAllow(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	user, project := t.TempDir(), t.TempDir()
+	writeFile(t, filepath.Join(user, "settings.yaml"), "env:\n  allow: [\"WORKFLOW_GUARD_*\", CI]\n")
+	writeFile(t, filepath.Join(project, "settings.yaml"), "env:\n  allow: [CI, GITHUB_REF]\n")
+
+	cfg, err := LoadConfigFrom([]string{user, project})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"SKIP_EDIT_CONFIRMATION", "WORKFLOW_GUARD_*", "CI", "GITHUB_REF"}
+	got := cfg.Settings.Env.Allow
+	if len(got) != len(want) {
+		t.Fatalf("env.allow = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("env.allow = %q, want %q", got, want)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, text string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
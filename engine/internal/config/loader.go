@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -40,6 +42,33 @@ type Action struct {
 	Then       *Action                `yaml:"then"`
 	Else       *Action                `yaml:"else"`
 	Transforms []Transform            `yaml:"transforms"`
+	// SuppressOutput hides the hook's stdout from the transcript
+	SuppressOutput bool `yaml:"suppress_output"`
+	// Set and Unset change session state keys (state actions)
+	Set   map[string]interface{} `yaml:"set"`
+	Unset []string               `yaml:"unset"`
+	// URL, Headers, Body and Retries configure webhook actions. Body is a
+	// JSON template; Timeout (milliseconds) bounds delivery including
+	// retries. URLEnv names a variable of the hook's environment to read
+	// the URL from for each event instead; a daemon's own environment,
+	// which ${VAR} in url expands from, is not the hook's.
+	URL     string            `yaml:"url"`
+	URLEnv  string            `yaml:"url_env"`
+	Headers map[string]string `yaml:"headers"`
+	Body    string            `yaml:"body"`
+	Retries int               `yaml:"retries"`
+	// Suggest attaches remediation to deny and ask decisions, rendered
+	// into the reason so the model can retry correctly
+	Suggest *Suggestion `yaml:"suggest"`
+}
+
+// Suggestion describes how to do what a denied tool call attempted
+type Suggestion struct {
+	// Command is an alternative command to run (a template)
+	Command string `yaml:"command"`
+	// ToolCall derives the equivalent Write or Edit call from the Bash
+	// command being denied (echo/printf/cat redirects, tee, sed -i)
+	ToolCall bool `yaml:"tool_call"`
 }
 
 // Transform represents an input transformation
@@ -51,8 +80,8 @@ type Transform struct {
 
 // Trigger defines when a rule should fire
 type Trigger struct {
-	Event   string `yaml:"event"`
-	Matcher string `yaml:"matcher"`
+	Event   string `yaml:"event"`   // Event type, or several separated by |
+	Matcher string `yaml:"matcher"` // Regex for tool_name
 }
 
 // Rule represents a complete rule definition
@@ -66,52 +95,226 @@ type Rule struct {
 	Trigger     Trigger    `yaml:"trigger"`
 	Conditions  *Condition `yaml:"conditions"`
 	Actions     []Action   `yaml:"actions"`
+	// OnError decides the outcome when the rule's conditions cannot be
+	// evaluated: allow, deny or ask. Empty uses the global on_error.
+	OnError string `yaml:"on_error"`
+}
+
+// On-error policies for rules and the dispatcher
+const (
+	OnErrorAllow = "allow" // Fail open: treat the rule as not matching
+	OnErrorDeny  = "deny"  // Fail closed: block the event
+	OnErrorAsk   = "ask"   // Ask the user (PreToolUse; deny elsewhere)
+)
+
+// ValidOnError reports whether a policy is one of allow, deny or ask
+func ValidOnError(policy string) bool {
+	return policy == OnErrorAllow || policy == OnErrorDeny || policy == OnErrorAsk
+}
+
+// EnvSettings controls which environment variables conditions can see
+type EnvSettings struct {
+	// Allow lists variable names or globs (e.g. WORKFLOW_GUARD_*) exposed
+	// to conditions under env.*
+	Allow []string `yaml:"allow"`
+}
+
+// Profile is a named strictness level that switches rules on or off by tag
+type Profile struct {
+	Description string   `yaml:"description"`
+	EnableTags  []string `yaml:"enable_tags"`
+	DisableTags []string `yaml:"disable_tags"`
+}
+
+// ProtectSettings extends the built-in tamper protection. Entries can only
+// add to what is protected; the built-in set cannot be reduced.
+type ProtectSettings struct {
+	Paths []string `yaml:"paths"`
+	Env   []string `yaml:"env"`
+}
+
+// AuditSettings controls the decision log written for every dispatch
+type AuditSettings struct {
+	// Disabled turns the decision log off
+	Disabled bool `yaml:"disabled"`
+	// Path of the JSONL log; rotated files get .1, .2, ... suffixes
+	Path string `yaml:"path"`
+	// MaxSizeMB rotates the log once it would grow past this size
+	MaxSizeMB int `yaml:"max_size_mb"`
+	// MaxFiles is how many rotated files to keep
+	MaxFiles int `yaml:"max_files"`
+	// MaxAgeDays deletes log files last written longer ago (0 keeps them)
+	MaxAgeDays int `yaml:"max_age_days"`
+	// RedactFields lists dotted record fields whose values are replaced
+	// before writing, e.g. tool_input.content. "*" matches any key or
+	// list element.
+	RedactFields []string `yaml:"redact_fields"`
+}
+
+// RedactionSettings extends the secret detectors applied to log sinks and
+// rendered messages
+type RedactionSettings struct {
+	Patterns []SecretPattern `yaml:"patterns"`
+	// DisableEntropy stops redacting random-looking tokens that match no
+	// pattern
+	DisableEntropy bool `yaml:"disable_entropy"`
+}
+
+// SecretPattern is a named regular expression for one kind of secret. With
+// a capture group, only the group is redacted.
+type SecretPattern struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
+}
+
+// AgentSettings describes the agents quality cycles dispatch and what each
+// may do, for agent.* fields and the agent-context builtin
+type AgentSettings struct {
+	// Markers are regular expressions that name the active agent in the
+	// session transcript with their first capture group; the last match
+	// wins
+	Markers []string `yaml:"markers"`
+	// Roles maps agent names to their permissions
+	Roles map[string]AgentRole `yaml:"roles"`
+	// Exempt lists globs of workflow metadata (tickets, handoffs) that any
+	// context may edit
+	Exempt []string `yaml:"exempt"`
+}
+
+// AgentRole is what one agent may do
+type AgentRole struct {
+	// Cycle is the quality cycle the agent belongs to (code, plugin,
+	// prompt, tech), "" for helpers such as Explore
+	Cycle string `yaml:"cycle"`
+	// Tools the agent may use; empty allows every tool
+	Tools []string `yaml:"tools"`
+	// Paths are globs of files the agent may modify; empty allows any file
+	Paths []string `yaml:"paths"`
+	// Stage is the agent's place in its cycle (creator, critic or
+	// expediter), recorded by cycle actions; "" keeps it out of the cycle
+	Stage string `yaml:"stage"`
 }
 
+// Settings holds engine-wide options from settings.yaml
+type Settings struct {
+	Env       EnvSettings        `yaml:"env"`
+	Profile   string             `yaml:"profile"`
+	Profiles  map[string]Profile `yaml:"profiles"`
+	Protect   ProtectSettings    `yaml:"protect"`
+	Audit     AuditSettings      `yaml:"audit"`
+	Redaction RedactionSettings  `yaml:"redaction"`
+	Agents    AgentSettings      `yaml:"agents"`
+	// OnError is the default policy for rules that fail to evaluate and
+	// for events the dispatcher cannot process (default
+	// WORKFLOW_GUARD_ON_ERROR from the hook's environment, else allow)
+	OnError string `yaml:"on_error"`
+}
+
+// DefaultEnvAllow is always exposed, before any configured allowlist
+var DefaultEnvAllow = []string{"SKIP_EDIT_CONFIRMATION"}
+
 // Config represents the complete loaded configuration
 type Config struct {
 	Rules      []Rule               `yaml:"rules"`
 	Conditions map[string]Condition `yaml:"conditions"`
 	Actions    map[string]Action    `yaml:"actions"`
+	Settings   Settings             `yaml:"-"`
 	ScriptsDir string               `yaml:"-"`
+	Integrity  IntegrityStatus      `yaml:"-"`
 }
 
 // LoadConfig loads and merges YAML configuration from standard paths
 func LoadConfig() (*Config, error) {
+	paths, err := SearchPaths(os.Getenv("CLAUDE_PROJECT_DIR"))
+	if err != nil {
+		return nil, err
+	}
+	return LoadConfigFrom(paths)
+}
+
+// SearchPaths returns the configuration directories in order of precedence
+// (later wins). projectDir may be empty.
+func SearchPaths(projectDir string) ([]string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, err
 	}
 
-	// Configuration search paths (in order of precedence)
 	configPaths := []string{
 		filepath.Join(homeDir, ".claude-hooks"),
 		filepath.Join(homeDir, ".claude"),
 	}
 
-	// Add project-level config if CLAUDE_PROJECT_DIR is set
-	if projectDir := os.Getenv("CLAUDE_PROJECT_DIR"); projectDir != "" {
+	// Add project-level config if a project directory is known
+	if projectDir != "" {
 		configPaths = append(configPaths, filepath.Join(projectDir, ".claude"))
 	}
 
+	return configPaths, nil
+}
+
+// ConfigFiles lists the YAML files read from each configuration directory
+var ConfigFiles = []string{"settings.yaml", "conditions.yaml", "actions.yaml", "rules.yaml", "hooks.yaml"}
+
+// Fingerprint summarizes the size and modification time of every config
+// file under the given paths. Two equal fingerprints mean nothing changed.
+func Fingerprint(configPaths []string) string {
+	var b strings.Builder
+	files := []string{ManifestPath()}
+	for _, basePath := range configPaths {
+		files = append(files, LayerFiles(basePath)...)
+	}
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String()
+}
+
+// LoadConfigFrom loads and merges YAML configuration from the given paths
+func LoadConfigFrom(configPaths []string) (*Config, error) {
 	config := &Config{
 		Rules:      []Rule{},
 		Conditions: make(map[string]Condition),
 		Actions:    make(map[string]Action),
+		Settings: Settings{
+			Env:      EnvSettings{Allow: append([]string{}, DefaultEnvAllow...)},
+			Profiles: make(map[string]Profile),
+			Agents:   AgentSettings{Roles: make(map[string]AgentRole)},
+		},
 	}
 
+	// Skip layers that drifted from the integrity manifest
+	config.Integrity, configPaths = verifyIntegrity(configPaths)
+
 	// Load and merge configs from all paths
 	for _, basePath := range configPaths {
 		if _, err := os.Stat(basePath); os.IsNotExist(err) {
 			continue
 		}
 
+		// Missing files are fine; files that don't parse are errors, so a
+		// typo can't silently switch off a layer's rules
+
+		// Load settings
+		settingsPath := filepath.Join(basePath, "settings.yaml")
+		if settings, err := loadSettings(settingsPath); err == nil {
+			mergeSettings(&config.Settings, settings)
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s: %w", settingsPath, err)
+		}
+
 		// Load conditions
 		condPath := filepath.Join(basePath, "conditions.yaml")
 		if conditions, err := loadConditions(condPath); err == nil {
 			for k, v := range conditions {
 				config.Conditions[k] = v
 			}
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s: %w", condPath, err)
 		}
 
 		// Load actions
@@ -120,17 +323,18 @@ func LoadConfig() (*Config, error) {
 			for k, v := range actions {
 				config.Actions[k] = v
 			}
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s: %w", actPath, err)
 		}
 
-		// Load rules
-		rulesPath := filepath.Join(basePath, "rules.yaml")
-		if rules, err := loadRules(rulesPath); err == nil {
-			config.Rules = append(config.Rules, rules...)
-		}
-		// Try alternative name
-		hooksPath := filepath.Join(basePath, "hooks.yaml")
-		if rules, err := loadRules(hooksPath); err == nil {
-			config.Rules = append(config.Rules, rules...)
+		// Load rules, also under the alternative name hooks.yaml
+		for _, name := range []string{"rules.yaml", "hooks.yaml"} {
+			rulesPath := filepath.Join(basePath, name)
+			if rules, err := loadRules(rulesPath); err == nil {
+				config.Rules = append(config.Rules, rules...)
+			} else if !os.IsNotExist(err) {
+				return nil, fmt.Errorf("%s: %w", rulesPath, err)
+			}
 		}
 
 		// Track scripts directory
@@ -152,7 +356,7 @@ func loadConditions(path string) (map[string]Condition, error) {
 	var wrapper struct {
 		Conditions map[string]Condition `yaml:"conditions"`
 	}
-	if err := yaml.Unmarshal(data, &wrapper); err != nil {
+	if err := decodeYAML(data, &wrapper); err != nil {
 		return nil, err
 	}
 
@@ -168,7 +372,7 @@ func loadActions(path string) (map[string]Action, error) {
 	var wrapper struct {
 		Actions map[string]Action `yaml:"actions"`
 	}
-	if err := yaml.Unmarshal(data, &wrapper); err != nil {
+	if err := decodeYAML(data, &wrapper); err != nil {
 		return nil, err
 	}
 
@@ -184,9 +388,101 @@ func loadRules(path string) ([]Rule, error) {
 	var wrapper struct {
 		Rules []Rule `yaml:"rules"`
 	}
-	if err := yaml.Unmarshal(data, &wrapper); err != nil {
+	if err := decodeYAML(data, &wrapper); err != nil {
 		return nil, err
 	}
 
 	return wrapper.Rules, nil
 }
+
+func loadSettings(path string) (*Settings, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var settings Settings
+	if err := decodeYAML(data, &settings); err != nil {
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+// mergeSettings applies a later layer's settings on top of earlier ones
+func mergeSettings(dst *Settings, src *Settings) {
+	for _, name := range src.Env.Allow {
+		if !contains(dst.Env.Allow, name) {
+			dst.Env.Allow = append(dst.Env.Allow, name)
+		}
+	}
+	if src.Profile != "" {
+		dst.Profile = src.Profile
+	}
+	if src.OnError != "" {
+		dst.OnError = src.OnError
+	}
+	for name, profile := range src.Profiles {
+		dst.Profiles[name] = profile
+	}
+	dst.Protect.Paths = append(dst.Protect.Paths, src.Protect.Paths...)
+	dst.Protect.Env = append(dst.Protect.Env, src.Protect.Env...)
+
+	dst.Audit.Disabled = dst.Audit.Disabled || src.Audit.Disabled
+	if src.Audit.Path != "" {
+		dst.Audit.Path = src.Audit.Path
+	}
+	if src.Audit.MaxSizeMB > 0 {
+		dst.Audit.MaxSizeMB = src.Audit.MaxSizeMB
+	}
+	if src.Audit.MaxFiles > 0 {
+		dst.Audit.MaxFiles = src.Audit.MaxFiles
+	}
+	if src.Audit.MaxAgeDays > 0 {
+		dst.Audit.MaxAgeDays = src.Audit.MaxAgeDays
+	}
+	for _, field := range src.Audit.RedactFields {
+		if !contains(dst.Audit.RedactFields, field) {
+			dst.Audit.RedactFields = append(dst.Audit.RedactFields, field)
+		}
+	}
+
+	dst.Redaction.Patterns = append(dst.Redaction.Patterns, src.Redaction.Patterns...)
+	dst.Redaction.DisableEntropy = dst.Redaction.DisableEntropy || src.Redaction.DisableEntropy
+
+	for _, marker := range src.Agents.Markers {
+		if !contains(dst.Agents.Markers, marker) {
+			dst.Agents.Markers = append(dst.Agents.Markers, marker)
+		}
+	}
+	for name, role := range src.Agents.Roles {
+		dst.Agents.Roles[name] = role
+	}
+	for _, glob := range src.Agents.Exempt {
+		if !contains(dst.Agents.Exempt, glob) {
+			dst.Agents.Exempt = append(dst.Agents.Exempt, glob)
+		}
+	}
+}
+
+// decodeYAML unmarshals YAML after expanding ${VAR} references in string values
+func decodeYAML(data []byte, out interface{}) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return err
+	}
+	if root.Kind == 0 {
+		return nil // Empty document
+	}
+	interpolateNode(&root, os.LookupEnv)
+	return root.Decode(out)
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package cycle
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/session"
+)
+
+// Stages of a quality cycle in order (agents.roles.<name>.stage)
+const (
+	Creator   = "creator"   // Implements: code-developer, plugin-engineer, ...
+	Critic    = "critic"    // Reviews and approves or requests changes
+	Expediter = "expediter" // Tests and accepts the approved work
+)
+
+// Stages lists the valid stages in cycle order
+var Stages = []string{Creator, Critic, Expediter}
+
+// Transition events
+const (
+	Start  = "start"  // Task PreToolUse: the agent is dispatched
+	Finish = "finish" // Task PostToolUse: the agent returned
+)
+
+// Verdicts reported by critics and expediters
+const (
+	Approved      = "approved"
+	ChangesNeeded = "changes_needed"
+)
+
+// StateKey is the session state key holding transitions by ticket
+const StateKey = "cycle"
+
+// maxTransitions bounds the history kept per ticket
+const maxTransitions = 100
+
+// verdictPattern finds a verdict in an agent's final report. Only the
+// uppercase forms count, so prose such as "not yet approved" is ignored;
+// the last one wins.
+var verdictPattern = regexp.MustCompile(`\b(APPROVED|NEEDS[_ ]CHANGES|CHANGES[_ ]REQUESTED|REJECTED)\b`)
+
+// Transition is one agent entering or leaving a ticket's cycle
+type Transition struct {
+	Agent   string    `json:"agent"`
+	Cycle   string    `json:"cycle,omitempty"`
+	Stage   string    `json:"stage"`
+	Event   string    `json:"event"`
+	Verdict string    `json:"verdict,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// Status summarises a ticket's transitions
+type Status struct {
+	Started  bool   // Any transition was recorded
+	Cycle    string // Cycle of the latest transition
+	Created  bool   // A creator ran
+	Approved bool   // A critic approved after the last creator started
+	Accepted bool   // An expediter approved after that approval
+	Last     *Transition
+	Pending  string // The stage that has to run next, "" when complete
+}
+
+// ValidStage reports whether stage is a cycle stage ("" is a helper agent
+// outside the cycle)
+func ValidStage(stage string) bool {
+	if stage == "" {
+		return true
+	}
+	for _, s := range Stages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// Verdict returns the last verdict in an agent's report, "" when it gives
+// none
+func Verdict(report string) string {
+	matches := verdictPattern.FindAllString(report, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	if matches[len(matches)-1] == "APPROVED" {
+		return Approved
+	}
+	return ChangesNeeded
+}
+
+// Record appends a transition to a ticket's history in session state
+func Record(state *session.State, ticket string, t Transition) {
+	all := load(state.Values)
+	history := append(all[ticket], t)
+	if len(history) > maxTransitions {
+		history = history[len(history)-maxTransitions:]
+	}
+	all[ticket] = history
+
+	// Store plain JSON values, as a state read back from disk holds
+	var value any
+	if data, err := json.Marshal(all); err == nil && json.Unmarshal(data, &value) == nil {
+		state.Set(StateKey, value)
+	}
+}
+
+// History returns a ticket's transitions from session state values
+func History(values map[string]any, ticket string) []Transition {
+	return load(values)[ticket]
+}
+
+func load(values map[string]any) map[string][]Transition {
+	all := map[string][]Transition{}
+	if data, err := json.Marshal(values[StateKey]); err == nil {
+		json.Unmarshal(data, &all)
+	}
+	if all == nil {
+		all = map[string][]Transition{}
+	}
+	return all
+}
+
+// Evaluate walks a ticket's transitions in order. A creator starting
+// withdraws earlier approvals, a critic's verdict sets approval and an
+// expediter's verdict accepts an approved change.
+func Evaluate(history []Transition) Status {
+	var s Status
+	for i := range history {
+		t := &history[i]
+		s.Started, s.Last = true, t
+		if t.Cycle != "" {
+			s.Cycle = t.Cycle
+		}
+		switch {
+		case t.Stage == Creator && t.Event == Start:
+			s.Created, s.Approved, s.Accepted = true, false, false
+		case t.Stage == Critic && t.Event == Finish:
+			s.Approved, s.Accepted = t.Verdict == Approved, false
+		case t.Stage == Expediter && t.Event == Finish:
+			s.Accepted = s.Approved && t.Verdict == Approved
+		}
+	}
+	switch {
+	case !s.Approved:
+		s.Pending = Critic
+	case !s.Accepted:
+		s.Pending = Expediter
+	}
+	return s
+}
+
+// Key names a ticket's history: its file name without .md, which is the
+// ticket_id of a valid ticket
+func Key(ticketPath string) string {
+	return strings.TrimSuffix(filepath.Base(ticketPath), ".md")
+}
+
+// Report returns the text of a Task tool_response, whether a plain string
+// or content blocks
+func Report(response any) string {
+	var parts []string
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case string:
+			parts = append(parts, v)
+		case []any:
+			for _, item := range v {
+				walk(item)
+			}
+		case map[string]any:
+			if text, ok := v["text"].(string); ok {
+				parts = append(parts, text)
+				return
+			}
+			walk(v["content"])
+		}
+	}
+	walk(response)
+	return strings.Join(parts, "\n")
+}
+
+// AgentName turns a Task subagent_type into an agent name: plugin
+// namespaces (qc-router:code-reviewer) are dropped
+func AgentName(subagentType string) string {
+	if i := strings.LastIndex(subagentType, ":"); i >= 0 {
+		return subagentType[i+1:]
+	}
+	return subagentType
+}
@@ -0,0 +1,141 @@
+package cycle
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/session"
+)
+
+func TestVerdict(t *testing.T) {
+	tests := []struct {
+		report string
+		want   string
+	}{
+		{"All good. APPROVED", Approved},
+		{"Verdict: NEEDS_CHANGES", ChangesNeeded},
+		{"NEEDS CHANGES", ChangesNeeded},
+		{"CHANGES_REQUESTED: fix the parser", ChangesNeeded},
+		{"REJECTED", ChangesNeeded},
+		{"First pass NEEDS_CHANGES; after the fix: APPROVED", Approved},
+		{"APPROVED at first, but then REJECTED", ChangesNeeded},
+		{"not yet approved", ""},
+		{"UNAPPROVED", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Verdict(tt.report); got != tt.want {
+			t.Errorf("Verdict(%q) = %q, want %q", tt.report, got, tt.want)
+		}
+	}
+}
+
+// step builds a transition: stage start, or stage finish with a verdict
+func step(stage, event, verdict string) Transition {
+	return Transition{Agent: stage + "-agent", Cycle: "code", Stage: stage, Event: event, Verdict: verdict}
+}
+
+func TestEvaluate(t *testing.T) {
+	var (
+		create       = step(Creator, Start, "")
+		created      = step(Creator, Finish, "")
+		review       = step(Critic, Start, "")
+		approve      = step(Critic, Finish, Approved)
+		reject       = step(Critic, Finish, ChangesNeeded)
+		test         = step(Expediter, Start, "")
+		accept       = step(Expediter, Finish, Approved)
+		testsFail    = step(Expediter, Finish, ChangesNeeded)
+		helperFinish = Transition{Agent: "Explore", Event: Finish, Verdict: Approved}
+	)
+	tests := []struct {
+		name     string
+		history  []Transition
+		approved bool
+		accepted bool
+		pending  string
+	}{
+		{"nothing recorded", nil, false, false, Critic},
+		{"created", []Transition{create, created}, false, false, Critic},
+		{"review started", []Transition{create, created, review}, false, false, Critic},
+		{"approved", []Transition{create, created, review, approve}, true, false, Expediter},
+		{"changes needed", []Transition{create, created, review, reject}, false, false, Critic},
+		{"accepted", []Transition{create, created, approve, test, accept}, true, true, ""},
+		{"tests fail", []Transition{create, created, approve, test, testsFail}, true, false, Expediter},
+		{"accepted, then tests fail", []Transition{create, approve, accept, testsFail}, true, false, Expediter},
+
+		// A creator starting again withdraws approval and acceptance
+		{"creator restarts after approval", []Transition{create, approve, create}, false, false, Critic},
+		{"creator restarts after acceptance", []Transition{create, approve, accept, create, created}, false, false, Critic},
+		{"approved again after restart", []Transition{create, approve, accept, create, approve}, true, false, Expediter},
+
+		// An expediter only accepts approved work
+		{"expediter before any review", []Transition{create, accept}, false, false, Critic},
+		{"expediter before approval", []Transition{create, reject, accept, approve}, true, false, Expediter},
+
+		// The critic's last verdict wins
+		{"rejected then approved", []Transition{create, reject, approve}, true, false, Expediter},
+		{"approved then rejected", []Transition{create, approve, reject}, false, false, Critic},
+		{"rejection withdraws acceptance", []Transition{create, approve, accept, reject}, false, false, Critic},
+
+		{"helpers are ignored", []Transition{create, approve, helperFinish}, true, false, Expediter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Evaluate(tt.history)
+			if s.Approved != tt.approved || s.Accepted != tt.accepted || s.Pending != tt.pending {
+				t.Errorf("approved %v, accepted %v, pending %q; want %v, %v, %q",
+					s.Approved, s.Accepted, s.Pending, tt.approved, tt.accepted, tt.pending)
+			}
+			if s.Started != (len(tt.history) > 0) {
+				t.Errorf("started = %v with %d transitions", s.Started, len(tt.history))
+			}
+			if len(tt.history) > 0 && s.Last.Agent != tt.history[len(tt.history)-1].Agent {
+				t.Errorf("last = %+v, want the final transition", s.Last)
+			}
+		})
+	}
+}
+
+func TestRecord(t *testing.T) {
+	state, release := session.NewStore(t.TempDir(), false).Acquire("s1")
+	defer release()
+
+	for i := 0; i < maxTransitions+5; i++ {
+		Record(state, "TICKET-s1-001", Transition{Agent: fmt.Sprint(i), Stage: Creator, Event: Start})
+	}
+	Record(state, "TICKET-s1-002", step(Critic, Finish, Approved))
+
+	history := History(state.Values, "TICKET-s1-001")
+	if len(history) != maxTransitions || history[0].Agent != "5" || history[len(history)-1].Agent != fmt.Sprint(maxTransitions+4) {
+		t.Errorf("kept %d transitions from %s, want the last %d", len(history), history[0].Agent, maxTransitions)
+	}
+	if other := History(state.Values, "TICKET-s1-002"); len(other) != 1 || other[0].Verdict != Approved {
+		t.Errorf("other ticket history = %+v", other)
+	}
+}
+
+func TestAgentName(t *testing.T) {
+	for subagent, want := range map[string]string{
+		"code-reviewer":           "code-reviewer",
+		"qc-router:code-reviewer": "code-reviewer",
+		"a:b:code-tester":         "code-tester",
+		"":                        "",
+	} {
+		if got := AgentName(subagent); got != want {
+			t.Errorf("AgentName(%q) = %q, want %q", subagent, got, want)
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	response := map[string]any{"content": []any{
+		map[string]any{"type": "text", "text": "Reviewed."},
+		map[string]any{"type": "text", "text": "APPROVED"},
+	}}
+	if got := Report(response); got != "Reviewed.\nAPPROVED" {
+		t.Errorf("Report = %q", got)
+	}
+	if got := Report("plain APPROVED"); got != "plain APPROVED" {
+		t.Errorf("Report(string) = %q", got)
+	}
+}
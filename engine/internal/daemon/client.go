@@ -0,0 +1,50 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/actions"
+)
+
+// DefaultTimeout bounds a forwarded dispatch, including the connect. It
+// is well above the default script condition timeout, so a slow script is
+// answered by the daemon rather than cut off.
+const DefaultTimeout = 10 * time.Second
+
+// ErrUnavailable means the daemon never received the request: nothing
+// ran, and the caller should evaluate the event in-process
+var ErrUnavailable = errors.New("daemon unavailable")
+
+// Forward sends a request to the daemon and returns its response. Errors
+// wrap ErrUnavailable when the request was not delivered. Any other error
+// came after the daemon had the event, which may have run its actions, so
+// the caller must not evaluate it again.
+func Forward(socketPath string, req *Request, timeout time.Duration) (*actions.Response, error) {
+	conn, err := net.DialTimeout("unix", socketPath, 50*time.Millisecond)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer conn.Close()
+	req.Deadline = time.Now().Add(timeout)
+	conn.SetDeadline(req.Deadline)
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	var reply Reply
+	if err := json.NewDecoder(conn).Decode(&reply); err != nil {
+		return nil, err
+	}
+	if reply.Error != "" {
+		return nil, errors.New(reply.Error)
+	}
+	if reply.Response == nil {
+		return nil, errors.New("daemon returned no response")
+	}
+	return reply.Response, nil
+}
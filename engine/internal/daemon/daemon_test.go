@@ -0,0 +1,142 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/session"
+)
+
+const testRules = `version: "1.0"
+rules:
+  - id: slow-script
+    enabled: true
+    priority: 200
+    trigger:
+      event: PreToolUse
+      matcher: "Read"
+    conditions:
+      all:
+        - type: script
+          script: %q
+          timeout: 5000
+    actions:
+      - type: decision
+        decision: allow
+
+  - id: log-reads
+    enabled: true
+    priority: 100
+    trigger:
+      event: PreToolUse
+      matcher: "Read"
+    actions:
+      - type: log
+        params:
+          log_file: %q
+
+  - id: deny-bash
+    enabled: true
+    priority: 100
+    trigger:
+      event: PreToolUse
+      matcher: "Bash"
+    actions:
+      - type: decision
+        decision: deny
+        message: "no shell"
+`
+
+// startServer runs a daemon for a temporary home whose only rules come
+// from testRules. It returns the socket and the files the rules write.
+func startServer(t *testing.T) (socket, marker, logFile string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	claude := filepath.Join(home, ".claude")
+	if err := os.MkdirAll(claude, 0755); err != nil {
+		t.Fatal(err)
+	}
+	marker = filepath.Join(home, "script-finished")
+	logFile = filepath.Join(home, "reads.jsonl")
+	script := filepath.Join(home, "slow.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nsleep 1\ntouch "+marker+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	rules := []byte(fmt.Sprintf(testRules, script, logFile))
+	if err := os.WriteFile(filepath.Join(claude, "rules.yaml"), rules, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unix socket paths are short; keep this one out of the long test dirs
+	dir, err := os.MkdirTemp("", "wg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket = filepath.Join(dir, "d.sock")
+
+	server := NewServer(socket)
+	server.Store = session.NewStore(t.TempDir(), true)
+	go server.ListenAndServe()
+	t.Cleanup(func() { server.Close() })
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(socket); err == nil {
+			return socket, marker, logFile
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("daemon did not start listening")
+	return
+}
+
+func request(tool string) *Request {
+	event := `{"hook_event_name":"PreToolUse","tool_name":"` + tool + `","tool_input":{}}`
+	return &Request{Event: []byte(event), Env: map[string]string{}}
+}
+
+func TestForwardDispatches(t *testing.T) {
+	socket, _, _ := startServer(t)
+
+	response, err := Forward(socket, request("Bash"), DefaultTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Decision != "deny" {
+		t.Errorf("decision = %q, want deny", response.Decision)
+	}
+}
+
+func TestForwardUnavailable(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "missing.sock")
+
+	_, err := Forward(socket, request("Bash"), DefaultTimeout)
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("error = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestForwardTimeoutStopsDaemon(t *testing.T) {
+	socket, marker, logFile := startServer(t)
+
+	_, err := Forward(socket, request("Read"), 200*time.Millisecond)
+	if err == nil {
+		t.Fatal("forward finished before the slow script")
+	}
+	if errors.Is(err, ErrUnavailable) {
+		t.Fatalf("error = %v; the daemon had the request, so it must not be retried", err)
+	}
+
+	// The script would have finished and the next rule logged by now
+	time.Sleep(1500 * time.Millisecond)
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("the daemon kept running the script after the client gave up")
+	}
+	if _, err := os.Stat(logFile); err == nil {
+		t.Error("the daemon kept dispatching rules after the client gave up")
+	}
+}
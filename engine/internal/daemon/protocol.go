@@ -0,0 +1,41 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/actions"
+)
+
+// Request is sent by the dispatcher client for every hook invocation.
+// The daemon evaluates it in the client's environment, not its own.
+type Request struct {
+	Event      json.RawMessage   `json:"event"`
+	Env        map[string]string `json:"env"`
+	ProjectDir string            `json:"project_dir"`
+	// Deadline is when the client stops waiting; the daemon stops
+	// evaluating then too
+	Deadline time.Time `json:"deadline,omitempty"`
+}
+
+// Reply carries the dispatch result back to the client
+type Reply struct {
+	Response *actions.Response `json:"response,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// SocketPath returns the per-user socket the daemon listens on.
+// WORKFLOW_GUARD_SOCKET overrides the default location under
+// $XDG_RUNTIME_DIR (or the temp directory when that is unset).
+func SocketPath() string {
+	if path := os.Getenv("WORKFLOW_GUARD_SOCKET"); path != "" {
+		return path
+	}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "workflow-guard", "dispatcher.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("workflow-guard-%d", os.Getuid()), "dispatcher.sock")
+}
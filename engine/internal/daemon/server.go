@@ -0,0 +1,180 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/rules"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/session"
+)
+
+// Server answers dispatch requests over a Unix socket. It keeps the
+// compiled configuration of every project it has seen and reloads it when
+// any of the underlying YAML files change.
+type Server struct {
+	SocketPath string
+	Store      *session.Store
+	Logf       func(format string, args ...any)
+
+	mu       sync.Mutex
+	configs  map[string]*cachedConfig
+	listener net.Listener
+}
+
+type cachedConfig struct {
+	cfg         *config.Config
+	paths       []string
+	fingerprint string
+}
+
+// NewServer creates a server with an in-memory session store
+func NewServer(socketPath string) *Server {
+	return &Server{
+		SocketPath: socketPath,
+		Store:      session.NewStore(session.DefaultDir(), true),
+		Logf:       func(string, ...any) {},
+		configs:    make(map[string]*cachedConfig),
+	}
+}
+
+// ListenAndServe binds the socket and serves until Close is called
+func (s *Server) ListenAndServe() error {
+	if err := os.MkdirAll(filepath.Dir(s.SocketPath), 0700); err != nil {
+		return err
+	}
+
+	// A socket that refuses connections is left over from a dead daemon
+	if _, err := os.Stat(s.SocketPath); err == nil {
+		if conn, err := net.DialTimeout("unix", s.SocketPath, 100*time.Millisecond); err == nil {
+			conn.Close()
+			return fmt.Errorf("daemon already listening on %s", s.SocketPath)
+		}
+		os.Remove(s.SocketPath)
+	}
+
+	listener, err := net.Listen("unix", s.SocketPath)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(s.SocketPath, 0600); err != nil {
+		listener.Close()
+		return err
+	}
+
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+// Close stops the listener and removes the socket
+func (s *Server) Close() error {
+	s.mu.Lock()
+	listener := s.listener
+	s.mu.Unlock()
+	if listener == nil {
+		return nil
+	}
+	err := listener.Close()
+	os.Remove(s.SocketPath)
+	return err
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(DefaultTimeout))
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(&Reply{Error: fmt.Sprintf("bad request: %v", err)})
+		return
+	}
+
+	// Stop evaluating when the client stops waiting: at its deadline, or
+	// when it hangs up first
+	deadline := req.Deadline
+	if deadline.IsZero() {
+		deadline = time.Now().Add(DefaultTimeout)
+	}
+	conn.SetDeadline(deadline)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	go func() {
+		var b [1]byte
+		conn.Read(b[:])
+		cancel()
+	}()
+
+	reply := s.serve(ctx, &req)
+	json.NewEncoder(conn).Encode(reply)
+}
+
+func (s *Server) serve(ctx context.Context, req *Request) (reply *Reply) {
+	defer func() {
+		if r := recover(); r != nil {
+			reply = &Reply{Error: fmt.Sprintf("daemon panic: %v", r)}
+		}
+	}()
+
+	cfg, err := s.config(req.ProjectDir)
+	if err != nil {
+		return &Reply{Error: fmt.Sprintf("failed to load config: %v", err)}
+	}
+
+	response, err := rules.RunContext(ctx, req.Event, req.Env, cfg, s.Store)
+	if err != nil {
+		return &Reply{Error: err.Error()}
+	}
+	return &Reply{Response: response}
+}
+
+// config returns the cached configuration for a project, reloading it when
+// the files it was built from have changed
+func (s *Server) config(projectDir string) (*config.Config, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cached, ok := s.configs[projectDir]
+	if !ok {
+		paths, err := config.SearchPaths(projectDir)
+		if err != nil {
+			return nil, err
+		}
+		cached = &cachedConfig{paths: paths}
+		s.configs[projectDir] = cached
+	}
+
+	fingerprint := config.Fingerprint(cached.paths)
+	if cached.cfg != nil && fingerprint == cached.fingerprint {
+		return cached.cfg, nil
+	}
+
+	cfg, err := config.LoadConfigFrom(cached.paths)
+	if err != nil {
+		return nil, err
+	}
+	if cached.cfg != nil {
+		s.Logf("reloaded config for project %q", projectDir)
+	}
+	cached.cfg = cfg
+	cached.fingerprint = fingerprint
+	return cfg, nil
+}
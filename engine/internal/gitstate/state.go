@@ -0,0 +1,86 @@
+package gitstate
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// State describes the git repository a hook event happened in
+type State struct {
+	Root     string // Top level of the working tree
+	Branch   string // Current branch, "" when detached
+	Head     string // Commit SHA of HEAD
+	Detached bool
+	// Project is the repository name, taken from the main worktree so
+	// linked worktrees report the same project
+	Project string
+	// Worktree is true inside a linked worktree (git worktree add)
+	Worktree bool
+	// MainRoot is the top level of the main worktree, Root outside
+	// linked worktrees
+	MainRoot string
+}
+
+// Read inspects the repository containing dir. ok is false outside a repo
+// or when git is unavailable.
+func Read(dir string) (State, bool) {
+	out, err := Git(dir, "rev-parse", "--show-toplevel", "--git-dir", "--git-common-dir")
+	if err != nil {
+		return State{}, false
+	}
+	lines := strings.Split(out, "\n")
+	if len(lines) < 3 {
+		return State{}, false
+	}
+
+	state := State{Root: lines[0]}
+	// Fails in a repository without commits
+	if head, err := Git(dir, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+		state.Head = head
+	}
+
+	gitDir := absolute(lines[1], dir)
+	commonDir := absolute(lines[2], dir)
+	state.Worktree = gitDir != commonDir
+	state.Project = filepath.Base(state.Root)
+	state.MainRoot = state.Root
+	if filepath.Base(commonDir) == ".git" {
+		state.MainRoot = filepath.Dir(commonDir)
+		state.Project = filepath.Base(state.MainRoot)
+	}
+
+	if branch, err := Git(dir, "symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		state.Branch = branch
+	} else {
+		state.Detached = true
+	}
+	return state, true
+}
+
+// Fields returns the state as a map for condition field access (git.*)
+func (s State) Fields() map[string]any {
+	return map[string]any{
+		"root":     s.Root,
+		"branch":   s.Branch,
+		"head":     s.Head,
+		"detached": s.Detached,
+		"project":  s.Project,
+		"worktree": s.Worktree,
+	}
+}
+
+// Git runs a git command in dir and returns its trimmed output
+func Git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+func absolute(path, dir string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,36 @@
+package rules
+
+import (
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+)
+
+// BuiltinRules are evaluated before every configured rule. They cannot be
+// disabled by config, profiles or priority, so a tool call can't switch off
+// the engine that is supervising it.
+var BuiltinRules = []config.Rule{
+	{
+		ID:   "builtin-tamper-protection",
+		Name: "Protect Hook Engine From Tampering",
+		Description: "Blocks edits, moves, deletions and permission changes of the " +
+			"engine's config search paths, binaries and the plugin's hooks.json, " +
+			"and inline assignments of bypass variables such as SKIP_EDIT_CONFIRMATION.",
+		Enabled:  true,
+		Priority: 1000,
+		OnError:  config.OnErrorDeny,
+		Tags:     []string{"builtin", "security", "tamper-protection"},
+		Trigger:  config.Trigger{Event: "PreToolUse"},
+		Conditions: &config.Condition{
+			Type:    "builtin",
+			Builtin: "tamper-protection",
+		},
+		Actions: []config.Action{{
+			Type:     "decision",
+			Decision: "deny",
+			Message: "SECURITY: Hook engine tamper protection\n\n" +
+				"Blocked: {{.tamper_reason}}\n\n" +
+				"The hook engine's configuration, binaries and hook registration " +
+				"cannot be changed from a tool call, and bypass variables cannot be " +
+				"set inline. Ask the user to make this change themselves.",
+		}},
+	},
+}
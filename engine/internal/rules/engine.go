@@ -1,8 +1,11 @@
 package rules
 
 import (
+	"fmt"
+	"os"
 	"regexp"
 	"sort"
+	"strings"
 
 	"github.com/dandoyle-pdm/workflow-guard/engine/internal/actions"
 	"github.com/dandoyle-pdm/workflow-guard/engine/internal/conditions"
@@ -11,10 +14,22 @@ import (
 
 // Dispatch evaluates rules and returns a response
 func Dispatch(event *conditions.HookEvent, cfg *config.Config) *actions.Response {
-	// Filter enabled rules
+	// Config that drifted from its manifest under a fail-closed policy:
+	// no rule runs
+	if cfg.Integrity.FailClosed != "" {
+		return integrityResponse(event, cfg)
+	}
+
+	// Resolve the active profile and expose it to conditions and templates
+	profile, _ := ActiveProfile(event, cfg)
+	if event.Raw != nil {
+		event.Raw["profile"] = profile
+	}
+
+	// Filter rules enabled under the profile
 	enabledRules := []config.Rule{}
 	for _, rule := range cfg.Rules {
-		if rule.Enabled {
+		if RuleActive(&rule, profile, cfg) {
 			enabledRules = append(enabledRules, rule)
 		}
 	}
@@ -24,35 +39,99 @@ func Dispatch(event *conditions.HookEvent, cfg *config.Config) *actions.Response
 		return enabledRules[i].Priority > enabledRules[j].Priority
 	})
 
-	// Evaluate rules in priority order
-	for _, rule := range enabledRules {
+	// Non-terminal output accumulates across rules
+	out := &actions.Response{ExitCode: 0, HookEvent: event.HookType}
+
+	// Built-in rules always run first, then configured rules in priority order
+	for _, rule := range append(append([]config.Rule{}, BuiltinRules...), enabledRules...) {
 		if !matchesTrigger(&rule, event) {
 			continue
 		}
+		// Nobody is waiting for the answer: run no more conditions or actions
+		if err := event.Context().Err(); err != nil {
+			out.Errors = append(out.Errors, fmt.Sprintf("dispatch stopped: %v", err))
+			return out
+		}
 
 		// Check conditions
-		if rule.Conditions != nil {
-			if !conditions.Evaluate(rule.Conditions, event, cfg) {
-				continue
+		matched, err := conditions.Evaluate(rule.Conditions, event, cfg)
+		if err != nil {
+			if resp := ruleError(&rule, event, cfg, out, err); resp != nil {
+				return resp
 			}
+			continue
+		}
+		if !matched {
+			continue
+		}
+		out.MatchedRules = append(out.MatchedRules, rule.ID)
+		if event.Raw != nil {
+			event.Raw["rule_id"] = rule.ID
 		}
 
-		// Execute actions
+		// Execute actions; one that fails to evaluate ends the rule
 		for _, action := range rule.Actions {
-			resp := actions.Execute(&action, event, cfg)
+			if err := event.Context().Err(); err != nil {
+				out.Errors = append(out.Errors, fmt.Sprintf("dispatch stopped: %v", err))
+				return out
+			}
+			resp, err := actions.Execute(&action, event, cfg, out)
+			if err != nil {
+				if resp := ruleError(&rule, event, cfg, out, err); resp != nil {
+					return resp
+				}
+				break
+			}
 			if resp != nil {
-				return resp // Terminal action
+				resp.HookEvent = event.HookType
+				resp.Merge(out)
+				return guardStopLoop(resp, event, &rule) // Terminal action
 			}
 		}
 	}
 
 	// No terminal action - continue normally
-	return &actions.Response{ExitCode: 0}
+	return out
+}
+
+// ruleError records a rule whose conditions, or the condition of one of
+// its conditional actions, could not be evaluated. It returns the rule's
+// response under its on_error policy, nil when the rule fails open.
+func ruleError(rule *config.Rule, event *conditions.HookEvent, cfg *config.Config, out *actions.Response, err error) *actions.Response {
+	policy := RuleOnError(rule, cfg, event.Env)
+	fmt.Fprintf(os.Stderr, "Rule %s: %v (on_error: %s)\n", rule.ID, err, policy)
+	out.Errors = append(out.Errors, fmt.Sprintf("%s: %v", rule.ID, err))
+	if policy == config.OnErrorAllow {
+		return nil
+	}
+	// A rule whose action failed has matched already
+	if n := len(out.MatchedRules); n == 0 || out.MatchedRules[n-1] != rule.ID {
+		out.MatchedRules = append(out.MatchedRules, rule.ID)
+	}
+	resp := ruleErrorResponse(rule, event, policy, err)
+	resp.Merge(out)
+	return guardStopLoop(resp, event, rule)
+}
+
+// guardStopLoop drops a block on Stop/SubagentStop when Claude is already
+// continuing because of an earlier stop-hook block. Blocking again would
+// keep the agent from ever finishing. Halting stays allowed.
+func guardStopLoop(resp *actions.Response, event *conditions.HookEvent, rule *config.Rule) *actions.Response {
+	if !event.StopHookActive || resp.Decision != "deny" {
+		return resp
+	}
+	if event.HookType != "Stop" && event.HookType != "SubagentStop" {
+		return resp
+	}
+	fmt.Fprintf(os.Stderr, "Rule %s: not blocking %s again (stop_hook_active)\n", rule.ID, event.HookType)
+	resp.Decision = ""
+	resp.Message = ""
+	return resp
 }
 
 func matchesTrigger(rule *config.Rule, event *conditions.HookEvent) bool {
 	// Check event type
-	if rule.Trigger.Event != "" && rule.Trigger.Event != event.HookType {
+	if rule.Trigger.Event != "" && !matchesEvent(rule.Trigger.Event, event.HookType) {
 		return false
 	}
 
@@ -66,3 +145,44 @@ func matchesTrigger(rule *config.Rule, event *conditions.HookEvent) bool {
 
 	return true
 }
+
+// integrityResponse stops tool calls and prompts while the config drifted
+// under a fail-closed policy. Other events pass, so that Stop can't keep a
+// session from ever ending; ask only exists on PreToolUse and denies
+// elsewhere.
+func integrityResponse(event *conditions.HookEvent, cfg *config.Config) *actions.Response {
+	decision := cfg.Integrity.FailClosed
+	switch event.HookType {
+	case "PreToolUse", "":
+	case "UserPromptSubmit":
+		decision = "deny"
+	default:
+		return &actions.Response{ExitCode: 0, HookEvent: event.HookType}
+	}
+
+	var b strings.Builder
+	b.WriteString("SECURITY: Hook configuration does not match its integrity manifest.\n\n")
+	for _, d := range cfg.Integrity.Drift {
+		fmt.Fprintf(&b, "  %s: %s\n", d.Kind, d.File)
+	}
+	b.WriteString("\nRun 'hookctl config verify' for details. Restore the reviewed files, " +
+		"or have the user re-lock with 'hookctl config lock'.")
+
+	return &actions.Response{
+		ExitCode:  0,
+		Decision:  decision,
+		Message:   b.String(),
+		HookEvent: event.HookType,
+	}
+}
+
+// matchesEvent compares an event type against a trigger event, which may
+// list several types separated by |
+func matchesEvent(trigger string, hookType string) bool {
+	for _, name := range strings.Split(trigger, "|") {
+		if strings.TrimSpace(name) == hookType {
+			return true
+		}
+	}
+	return false
+}
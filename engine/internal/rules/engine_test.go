@@ -0,0 +1,81 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/conditions"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+)
+
+func TestDispatchFailClosed(t *testing.T) {
+	tests := []struct {
+		policy   string
+		hookType string
+		want     string
+	}{
+		{config.OnDriftDeny, "PreToolUse", "deny"},
+		{config.OnDriftAsk, "PreToolUse", "ask"},
+		{config.OnDriftAsk, "UserPromptSubmit", "deny"},
+		{config.OnDriftDeny, "Stop", ""},
+		{config.OnDriftDeny, "SubagentStop", ""},
+		{config.OnDriftAsk, "PostToolUse", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy+" "+tt.hookType, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Integrity.FailClosed = tt.policy
+			cfg.Integrity.Drift = []config.Drift{{File: "rules.yaml", Kind: "modified"}}
+			event := &conditions.HookEvent{HookType: tt.hookType, StopHookActive: true}
+
+			resp := Dispatch(event, cfg)
+			if resp.Decision != tt.want {
+				t.Errorf("decision = %q, want %q", resp.Decision, tt.want)
+			}
+			if tt.want != "" && resp.HookOutput() == nil {
+				t.Errorf("%s decision produces no hook output", tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatchConditionalActionError(t *testing.T) {
+	tests := []struct {
+		onError string
+		want    string
+	}{
+		{config.OnErrorAllow, ""},
+		{config.OnErrorDeny, "deny"},
+		{config.OnErrorAsk, "ask"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.onError, func(t *testing.T) {
+			cfg := &config.Config{Rules: []config.Rule{{
+				ID:      "conditional",
+				Enabled: true,
+				OnError: tt.onError,
+				Trigger: config.Trigger{Event: "PreToolUse"},
+				Actions: []config.Action{{
+					Type:      "conditional",
+					Condition: &config.Condition{Type: "regex", Field: "tool_name", Pattern: "("},
+					Then:      &config.Action{Type: "decision", Decision: "allow"},
+					Else:      &config.Action{Type: "decision", Decision: "allow"},
+				}},
+			}}}
+			event, err := conditions.NewEvent([]byte(`{"hook_event_name":"PreToolUse","tool_name":"Bash"}`), map[string]string{}, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp := Dispatch(event, cfg)
+			if resp.Decision != tt.want {
+				t.Errorf("decision = %q, want %q", resp.Decision, tt.want)
+			}
+			if len(resp.Errors) != 1 {
+				t.Errorf("errors = %v, want the regex error", resp.Errors)
+			}
+			if len(resp.MatchedRules) != 1 {
+				t.Errorf("matched rules = %v, want [conditional]", resp.MatchedRules)
+			}
+		})
+	}
+}
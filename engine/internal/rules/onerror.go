@@ -0,0 +1,89 @@
+package rules
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/actions"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/conditions"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+)
+
+// OnErrorEnvVar sets the policy when settings.yaml doesn't
+const OnErrorEnvVar = "WORKFLOW_GUARD_ON_ERROR"
+
+// DefaultOnError returns the policy for failures before any configuration
+// is available: WORKFLOW_GUARD_ON_ERROR when valid, otherwise allow
+func DefaultOnError() string {
+	return orAllow(os.Getenv(OnErrorEnvVar))
+}
+
+// GlobalOnError returns the on_error setting, else WORKFLOW_GUARD_ON_ERROR
+// in env, the hook's environment, else allow. The variable is not
+// interpolated into settings: a daemon loads and caches config in its own
+// environment.
+func GlobalOnError(cfg *config.Config, env map[string]string) string {
+	if config.ValidOnError(cfg.Settings.OnError) {
+		return cfg.Settings.OnError
+	}
+	return orAllow(env[OnErrorEnvVar])
+}
+
+// RuleOnError returns the policy of a rule, falling back to the global one
+func RuleOnError(rule *config.Rule, cfg *config.Config, env map[string]string) string {
+	if config.ValidOnError(rule.OnError) {
+		return rule.OnError
+	}
+	return GlobalOnError(cfg, env)
+}
+
+// orAllow returns policy when it is valid, else allow
+func orAllow(policy string) string {
+	if config.ValidOnError(policy) {
+		return policy
+	}
+	return config.OnErrorAllow
+}
+
+// ErrorResponse answers an event the engine could not process at all. A
+// deny exits 2, which blocks every event type even when the event itself
+// could not be parsed; ask only exists on PreToolUse and denies elsewhere.
+// Allowing still tells the user the guards did not run.
+func ErrorResponse(policy, hookType string, err error) *actions.Response {
+	message := fmt.Sprintf("workflow-guard could not evaluate this event: %v", err)
+	switch {
+	case policy == config.OnErrorAsk && hookType == "PreToolUse":
+		return &actions.Response{
+			Decision:  "ask",
+			Message:   message + "\n\nNo rules were checked (on_error: ask).",
+			HookEvent: hookType,
+		}
+	case policy == config.OnErrorDeny || policy == config.OnErrorAsk:
+		return &actions.Response{
+			ExitCode:  2,
+			Decision:  "deny",
+			Message:   message + "\n\nBlocked because on_error is " + policy + ". Ask the user to fix the hook engine.",
+			HookEvent: hookType,
+		}
+	default:
+		return &actions.Response{
+			HookEvent:      hookType,
+			SystemMessages: []string{message + " (on_error: allow, no rules were checked)"},
+		}
+	}
+}
+
+// ruleErrorResponse is the decision for a rule whose conditions failed to
+// evaluate under a deny or ask policy
+func ruleErrorResponse(rule *config.Rule, event *conditions.HookEvent, policy string, err error) *actions.Response {
+	decision := "deny"
+	if policy == config.OnErrorAsk && event.HookType == "PreToolUse" {
+		decision = "ask"
+	}
+	return &actions.Response{
+		Decision: decision,
+		Message: fmt.Sprintf("Rule %s could not be evaluated: %v\n\n"+
+			"The rule fails closed (on_error: %s). Ask the user to fix the rule's configuration.", rule.ID, err, policy),
+		HookEvent: event.HookType,
+	}
+}
@@ -0,0 +1,34 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+)
+
+func TestRuleOnError(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		settings string
+		env      string
+		want     string
+	}{
+		{"default", "", "", "", config.OnErrorAllow},
+		{"hook environment", "", "", "deny", config.OnErrorDeny},
+		{"invalid environment", "", "", "block", config.OnErrorAllow},
+		{"settings over environment", "", "ask", "deny", config.OnErrorAsk},
+		{"rule over settings", "deny", "allow", "ask", config.OnErrorDeny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Settings.OnError = tt.settings
+			rule := &config.Rule{ID: "r", OnError: tt.rule}
+			env := map[string]string{OnErrorEnvVar: tt.env}
+			if got := RuleOnError(rule, cfg, env); got != tt.want {
+				t.Errorf("RuleOnError = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
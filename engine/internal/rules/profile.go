@@ -0,0 +1,60 @@
+package rules
+
+import (
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/conditions"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+)
+
+// ProfileEnvVar selects the active profile for a process
+const ProfileEnvVar = "WORKFLOW_GUARD_PROFILE"
+
+// ProfileStateKey selects the active profile for a session
+const ProfileStateKey = "profile"
+
+// ActiveProfile resolves the profile in effect for an event. A session-state
+// key wins over the environment, which wins over the configured default.
+// Returns the profile name and where it was selected ("" when none).
+func ActiveProfile(event *conditions.HookEvent, cfg *config.Config) (string, string) {
+	if event != nil && event.Session != nil {
+		if name := event.Session.GetString(ProfileStateKey); name != "" {
+			return name, "session"
+		}
+	}
+	if event != nil {
+		if name := event.Env[ProfileEnvVar]; name != "" {
+			return name, "env"
+		}
+	}
+	if cfg.Settings.Profile != "" {
+		return cfg.Settings.Profile, "config"
+	}
+	return "", ""
+}
+
+// RuleActive reports whether a rule is enabled under a profile. Disabled
+// tags win over enabled tags; rules with neither keep their own setting.
+// Unknown profile names leave every rule as configured.
+func RuleActive(rule *config.Rule, profileName string, cfg *config.Config) bool {
+	profile, ok := cfg.Settings.Profiles[profileName]
+	if !ok {
+		return rule.Enabled
+	}
+	if hasAnyTag(rule.Tags, profile.DisableTags) {
+		return false
+	}
+	if hasAnyTag(rule.Tags, profile.EnableTags) {
+		return true
+	}
+	return rule.Enabled
+}
+
+func hasAnyTag(tags []string, wanted []string) bool {
+	for _, tag := range tags {
+		for _, w := range wanted {
+			if tag == w {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/actions"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/audit"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/conditions"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/session"
+)
+
+// Run parses a raw hook event, attaches its session state and dispatches it.
+// Both the dispatcher daemon and the in-process fallback go through Run.
+// Every dispatch is recorded in the decision log.
+func Run(data []byte, env map[string]string, cfg *config.Config, store *session.Store) (*actions.Response, error) {
+	return RunContext(context.Background(), data, env, cfg, store)
+}
+
+// RunContext is Run for a caller that may stop waiting: once ctx is done,
+// no further condition or action runs
+func RunContext(ctx context.Context, data []byte, env map[string]string, cfg *config.Config, store *session.Store) (*actions.Response, error) {
+	logger := audit.NewLogger(cfg.Settings.Audit)
+
+	event, err := conditions.NewEvent(data, env, cfg)
+	if err != nil {
+		err = fmt.Errorf("failed to parse event: %w", err)
+		writeRecord(logger, &audit.Record{Timestamp: time.Now(), Rules: []string{}, Error: err.Error()})
+		return nil, err
+	}
+
+	event.SetContext(ctx)
+
+	state, release := store.Acquire(event.SessionID)
+	event.AttachSession(state)
+
+	start := time.Now()
+	response := Dispatch(event, cfg)
+	latency := time.Since(start)
+
+	if err := release(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save session state: %v\n", err)
+	}
+
+	writeRecord(logger, decisionRecord(event, response, cfg, start, latency))
+	return response, nil
+}
+
+// decisionRecord describes the outcome of one dispatch,
+// with secrets redacted
+func decisionRecord(event *conditions.HookEvent, response *actions.Response, cfg *config.Config, start time.Time, latency time.Duration) *audit.Record {
+	record := &audit.Record{
+		Timestamp: start,
+		SessionID: event.SessionID,
+		Cwd:       event.Cwd,
+		Event:     event.HookType,
+		ToolName:  event.ToolName,
+		Rules:     response.MatchedRules,
+		Decision:  response.Decision,
+		Message:   actions.Redact(response.Message, cfg),
+		Halt:      response.Halt,
+		Error:     strings.Join(response.Errors, "; "),
+		LatencyMS: float64(latency.Microseconds()) / 1000,
+	}
+	if profile, ok := event.Raw["profile"].(string); ok {
+		record.Profile = profile
+	}
+	if record.Rules == nil {
+		record.Rules = []string{}
+	}
+	if response.Halt && record.Message == "" {
+		record.Message = actions.Redact(response.StopReason, cfg)
+	}
+	if input, ok := actions.RedactValue(event.ToolInput, cfg).(map[string]any); ok && len(input) > 0 {
+		record.ToolInput = input
+	}
+	return record
+}
+
+func writeRecord(logger *audit.Logger, record *audit.Record) {
+	if err := logger.Write(record); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write decision log: %v\n", err)
+	}
+}
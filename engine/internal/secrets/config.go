@@ -0,0 +1,43 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/config"
+)
+
+// The scanner for the most recently used config. The daemon keeps one
+// config until it reloads, so this avoids recompiling detectors per event.
+var cached struct {
+	sync.Mutex
+	cfg     *config.Config
+	scanner *Scanner
+}
+
+// ForConfig returns the scanner for a config: the builtin detectors, the
+// patterns from settings.yaml and, unless disabled, entropy scoring
+func ForConfig(cfg *config.Config) *Scanner {
+	cached.Lock()
+	defer cached.Unlock()
+	if cached.cfg == cfg && cached.scanner != nil {
+		return cached.scanner
+	}
+
+	scanner, err := NewScanner(ConfigPatterns(cfg), !cfg.Settings.Redaction.DisableEntropy)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Redaction: %v\n", err)
+	}
+	cached.cfg, cached.scanner = cfg, scanner
+	return scanner
+}
+
+// ConfigPatterns converts the redaction patterns from settings.yaml
+func ConfigPatterns(cfg *config.Config) []Pattern {
+	var patterns []Pattern
+	for _, p := range cfg.Settings.Redaction.Patterns {
+		patterns = append(patterns, Pattern{Name: p.Name, Pattern: p.Pattern})
+	}
+	return patterns
+}
@@ -0,0 +1,186 @@
+package secrets
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Detector finds one kind of secret. When the pattern has a capture group,
+// only the group is the secret (e.g. the value of aws_secret_access_key=...).
+type Detector struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// Match is one secret found in a text
+type Match struct {
+	Detector string
+	Start    int // Byte offsets of the secret
+	End      int
+	Line     int // 1-based line of Start
+}
+
+// Builtin detectors for well-known credential formats
+var Builtin = []Detector{
+	{"aws-access-key-id", regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[0-9A-Z]{16}\b`)},
+	{"aws-secret-access-key", regexp.MustCompile(`(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`)},
+	{"github-token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,255}\b`)},
+	{"github-pat", regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,255}\b`)},
+	{"gitlab-token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{"slack-token", regexp.MustCompile(`\bxox[baprs]-[A-Za-z0-9-]{10,}\b`)},
+	{"slack-webhook", regexp.MustCompile(`https://hooks\.slack\.com/services/[A-Za-z0-9/]+`)},
+	{"stripe-key", regexp.MustCompile(`\b(?:sk|rk)_live_[A-Za-z0-9]{20,}\b`)},
+	{"anthropic-key", regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}\b`)},
+	{"openai-key", regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9_-]{20,}\b`)},
+	{"google-api-key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY(?: BLOCK)?-----(?s:.*?)(?:-----END [A-Z0-9 ]*PRIVATE KEY(?: BLOCK)?-----|$)`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{"password-in-url", regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+:([^/\s:@]{3,})@`)},
+}
+
+// Entropy detection defaults. Random base64 of 32 characters averages about
+// 4.6 bits per character, while identifiers and paths stay nearer 4.
+const (
+	DefaultEntropyThreshold = 4.3
+	DefaultEntropyMinLength = 32
+)
+
+var entropyToken = regexp.MustCompile(`[A-Za-z0-9+/=_-]{20,}`)
+
+var hexOnly = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+
+// Scanner finds secrets using detectors and, optionally, entropy scoring
+type Scanner struct {
+	Detectors []Detector
+	// Entropy enables detection of random-looking tokens
+	Entropy          bool
+	EntropyThreshold float64
+	EntropyMinLength int
+}
+
+// Pattern is a config-defined detector
+type Pattern struct {
+	Name    string
+	Pattern string
+}
+
+// NewScanner builds a scanner from the builtin detectors plus custom
+// patterns. Patterns that fail to compile are returned as an error along
+// with a scanner that skips them.
+func NewScanner(custom []Pattern, entropy bool) (*Scanner, error) {
+	scanner := &Scanner{
+		Detectors:        append([]Detector{}, Builtin...),
+		Entropy:          entropy,
+		EntropyThreshold: DefaultEntropyThreshold,
+		EntropyMinLength: DefaultEntropyMinLength,
+	}
+	var problems []string
+	for _, p := range custom {
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", p.Name, err))
+			continue
+		}
+		name := p.Name
+		if name == "" {
+			name = "custom"
+		}
+		scanner.Detectors = append(scanner.Detectors, Detector{Name: name, Pattern: re})
+	}
+	if len(problems) > 0 {
+		return scanner, fmt.Errorf("invalid secret patterns: %s", strings.Join(problems, "; "))
+	}
+	return scanner, nil
+}
+
+// Find returns the secrets in text, ordered by position, without overlaps
+func (s *Scanner) Find(text string) []Match {
+	var matches []Match
+	for _, d := range s.Detectors {
+		for _, loc := range d.Pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			if len(loc) >= 4 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+			matches = append(matches, Match{Detector: d.Name, Start: start, End: end})
+		}
+	}
+
+	if s.Entropy {
+		for _, loc := range entropyToken.FindAllStringIndex(text, -1) {
+			if s.HighEntropy(text[loc[0]:loc[1]]) {
+				matches = append(matches, Match{Detector: "high-entropy-string", Start: loc[0], End: loc[1]})
+			}
+		}
+	}
+
+	// Earliest first; on ties prefer the longer (named detectors come first)
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Start != matches[j].Start {
+			return matches[i].Start < matches[j].Start
+		}
+		return matches[i].End > matches[j].End
+	})
+	var result []Match
+	lastEnd := -1
+	for _, m := range matches {
+		if m.Start < lastEnd {
+			continue
+		}
+		m.Line = strings.Count(text[:m.Start], "\n") + 1
+		result = append(result, m)
+		lastEnd = m.End
+	}
+	return result
+}
+
+// Redact replaces every secret in text with [REDACTED:<detector>]
+func (s *Scanner) Redact(text string) string {
+	matches := s.Find(text)
+	if len(matches) == 0 {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(text[last:m.Start])
+		b.WriteString("[REDACTED:" + m.Detector + "]")
+		last = m.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// HighEntropy reports whether a token looks like random key material.
+// Hex strings are skipped since commit SHAs and checksums are everywhere.
+func (s *Scanner) HighEntropy(token string) bool {
+	if len(token) < s.EntropyMinLength || hexOnly.MatchString(token) {
+		return false
+	}
+	// Keys mix letters and digits; identifiers and words rarely do
+	if !strings.ContainsAny(token, "0123456789") || strings.ToLower(token) == token || strings.ToUpper(token) == token {
+		return false
+	}
+	return Entropy(token) >= s.EntropyThreshold
+}
+
+// Entropy returns the Shannon entropy of s in bits per character
+func Entropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	for _, c := range s {
+		counts[c]++
+	}
+	total := float64(len([]rune(s)))
+	var entropy float64
+	for _, n := range counts {
+		p := float64(n) / total
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -0,0 +1,64 @@
+package session
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AgentsKey is the state key holding the session's active subagents
+const AgentsKey = "agents"
+
+// Agent is a subagent started by a Task call that hasn't returned yet
+type Agent struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	ToolUseID   string    `json:"tool_use_id,omitempty"`
+	Started     time.Time `json:"started"`
+}
+
+// Agents returns the active subagents, the most recently started last
+func (s *State) Agents() []Agent {
+	var agents []Agent
+	if data, err := json.Marshal(s.Values[AgentsKey]); err == nil {
+		json.Unmarshal(data, &agents)
+	}
+	return agents
+}
+
+// PushAgent records a subagent starting
+func (s *State) PushAgent(agent Agent) {
+	s.setAgents(append(s.Agents(), agent))
+}
+
+// PopAgent removes a returning subagent: the one started by the same tool
+// call, else the latest of that name. It reports whether one was found.
+func (s *State) PopAgent(toolUseID, name string) bool {
+	agents := s.Agents()
+	at := -1
+	for i := len(agents) - 1; i >= 0; i-- {
+		if toolUseID != "" && agents[i].ToolUseID == toolUseID {
+			at = i
+			break
+		}
+		if at < 0 && agents[i].Name == name {
+			at = i
+		}
+	}
+	if at < 0 {
+		return false
+	}
+	s.setAgents(append(agents[:at], agents[at+1:]...))
+	return true
+}
+
+// setAgents stores plain JSON values, as a state read back from disk holds
+func (s *State) setAgents(agents []Agent) {
+	if len(agents) == 0 {
+		s.Delete(AgentsKey)
+		return
+	}
+	var value any
+	if data, err := json.Marshal(agents); err == nil && json.Unmarshal(data, &value) == nil {
+		s.Set(AgentsKey, value)
+	}
+}
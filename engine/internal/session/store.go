@@ -0,0 +1,284 @@
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// State holds key/value data that persists across hook invocations of one
+// Claude Code session
+type State struct {
+	ID      string         `json:"session_id"`
+	Values  map[string]any `json:"values"`
+	Updated time.Time      `json:"updated"`
+	dirty   bool
+}
+
+// Get returns the value stored under key, or nil
+func (s *State) Get(key string) any {
+	return s.Values[key]
+}
+
+// GetString returns the value stored under key as a string
+func (s *State) GetString(key string) string {
+	if v, ok := s.Values[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// Set stores a value and marks the state for saving
+func (s *State) Set(key string, value any) {
+	s.Values[key] = value
+	s.dirty = true
+}
+
+// Delete removes a value and marks the state for saving
+func (s *State) Delete(key string) {
+	if _, exists := s.Values[key]; exists {
+		delete(s.Values, key)
+		s.dirty = true
+	}
+}
+
+// Dirty reports whether the state changed since it was loaded
+func (s *State) Dirty() bool {
+	return s.dirty
+}
+
+// Store loads and saves session state as one JSON file per session.
+// A store that keeps states caches them in memory between calls (used by the
+// dispatcher daemon) but still writes through to disk, so in-process
+// dispatchers see the same state when the daemon is unavailable.
+type Store struct {
+	dir   string
+	keep  bool
+	mu    sync.Mutex
+	locks map[string]*sessionLock
+	cache map[string]cachedState
+}
+
+// sessionLock serializes a session within the process. It is dropped
+// from the store once nobody holds or waits for it.
+type sessionLock struct {
+	sync.Mutex
+	users int
+}
+
+type cachedState struct {
+	state   *State
+	modTime time.Time
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// DefaultDir returns the session state directory.
+// WORKFLOW_GUARD_STATE_DIR overrides the default ~/.claude/workflow-guard/sessions.
+func DefaultDir() string {
+	if dir := os.Getenv("WORKFLOW_GUARD_STATE_DIR"); dir != "" {
+		return dir
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "workflow-guard", "sessions")
+	}
+	return filepath.Join(homeDir, ".claude", "workflow-guard", "sessions")
+}
+
+// NewStore creates a store rooted at dir
+func NewStore(dir string, keep bool) *Store {
+	return &Store{
+		dir:   dir,
+		keep:  keep,
+		locks: make(map[string]*sessionLock),
+		cache: make(map[string]cachedState),
+	}
+}
+
+// Acquire locks and loads the state of a session. The returned release
+// function saves the state if it changed and unlocks it; a state left
+// empty is removed along with its lock file, so sessions that are only
+// read leave nothing behind. Sessions without an ID get a throwaway state
+// that is never saved.
+func (s *Store) Acquire(id string) (*State, func() error) {
+	if id == "" {
+		return newState(id), func() error { return nil }
+	}
+
+	lock := s.lock(id)
+
+	// Serialize with other dispatcher processes
+	fileLock := s.lockFile(id)
+
+	path := s.path(id)
+	state := s.load(id, path)
+
+	release := func() error {
+		defer s.unlock(id, lock)
+		defer unlockFile(fileLock)
+
+		if state.dirty && len(state.Values) == 0 {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			state.dirty = false
+			s.forget(id)
+		}
+		if !state.dirty {
+			// Still holding the lock: nobody can be using the file
+			if _, err := os.Stat(path); os.IsNotExist(err) && fileLock != nil {
+				os.Remove(fileLock.Name())
+			}
+			return nil
+		}
+		state.Updated = time.Now().UTC()
+		if err := writeState(path, state); err != nil {
+			return err
+		}
+		state.dirty = false
+		if s.keep {
+			s.remember(id, path, state)
+		}
+		return nil
+	}
+	return state, release
+}
+
+// lock takes the in-process lock of a session
+func (s *Store) lock(id string) *sessionLock {
+	s.mu.Lock()
+	lock, ok := s.locks[id]
+	if !ok {
+		lock = &sessionLock{}
+		s.locks[id] = lock
+	}
+	lock.users++
+	s.mu.Unlock()
+	lock.Lock()
+	return lock
+}
+
+// unlock releases a session's in-process lock, dropping it when no other
+// caller is waiting for it
+func (s *Store) unlock(id string, lock *sessionLock) {
+	s.mu.Lock()
+	lock.users--
+	if lock.users == 0 {
+		delete(s.locks, id)
+	}
+	s.mu.Unlock()
+	lock.Unlock()
+}
+
+// path returns the file a session's state is stored in
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, unsafeChars.ReplaceAllString(id, "_")+".json")
+}
+
+func (s *Store) load(id, path string) *State {
+	info, statErr := os.Stat(path)
+
+	if s.keep && statErr == nil {
+		s.mu.Lock()
+		cached, ok := s.cache[id]
+		s.mu.Unlock()
+		if ok && cached.modTime.Equal(info.ModTime()) {
+			return cached.state
+		}
+	}
+
+	state := newState(id)
+	if statErr == nil {
+		if data, err := os.ReadFile(path); err == nil {
+			if json.Unmarshal(data, state) == nil && state.Values == nil {
+				state.Values = make(map[string]any)
+			}
+		}
+		state.ID = id
+	}
+	if s.keep && statErr == nil {
+		s.remember(id, path, state)
+	}
+	return state
+}
+
+func (s *Store) remember(id, path string, state *State) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	s.cache[id] = cachedState{state: state, modTime: info.ModTime()}
+	s.mu.Unlock()
+}
+
+func (s *Store) forget(id string) {
+	s.mu.Lock()
+	delete(s.cache, id)
+	s.mu.Unlock()
+}
+
+// lockFile takes the lock file of a session. The previous holder may have
+// removed the file while we waited for it, and a lock on a removed file
+// excludes nobody, so that case retries on a fresh file.
+func (s *Store) lockFile(id string) *os.File {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil
+	}
+	path := s.path(id) + ".lock"
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+		if err != nil {
+			return nil
+		}
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+			f.Close()
+			return nil
+		}
+		held, heldErr := f.Stat()
+		current, err := os.Stat(path)
+		if heldErr != nil || (err != nil && !os.IsNotExist(err)) || (err == nil && os.SameFile(held, current)) {
+			return f
+		}
+		unlockFile(f)
+	}
+}
+
+func unlockFile(f *os.File) {
+	if f == nil {
+		return
+	}
+	syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	f.Close()
+}
+
+func newState(id string) *State {
+	return &State{ID: id, Values: make(map[string]any)}
+}
+
+// writeState replaces the state file atomically
+func writeState(path string, state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".state-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
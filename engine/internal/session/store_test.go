@@ -0,0 +1,142 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAcquireReadOnlyLeavesNothing(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir, false)
+
+	state, release := store.Acquire("session-1")
+	if len(state.Values) != 0 {
+		t.Fatalf("new session has values: %v", state.Values)
+	}
+	if err := release(); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	assertFiles(t, dir)
+}
+
+func TestAcquireSavesAndRemovesEmptyState(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir, false)
+
+	state, release := store.Acquire("session-1")
+	state.Set("ticket", "TICKET-foo-001")
+	if err := release(); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	assertFiles(t, dir, "session-1.json", "session-1.json.lock")
+
+	state, release = store.Acquire("session-1")
+	if got := state.GetString("ticket"); got != "TICKET-foo-001" {
+		t.Fatalf("ticket = %q, want TICKET-foo-001", got)
+	}
+	state.Delete("ticket")
+	if err := release(); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	assertFiles(t, dir)
+}
+
+// Separate stores stand in for separate dispatcher processes: only the
+// file lock keeps them apart, including while lock files are removed.
+func TestAcquireExcludesOtherStores(t *testing.T) {
+	dir := t.TempDir()
+	var inside, overlaps int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				state, release := NewStore(dir, false).Acquire("session-1")
+				if atomic.AddInt32(&inside, 1) > 1 {
+					atomic.AddInt32(&overlaps, 1)
+				}
+				time.Sleep(100 * time.Microsecond)
+				if state.Get("flag") == nil {
+					state.Set("flag", true)
+				} else {
+					state.Delete("flag")
+				}
+				atomic.AddInt32(&inside, -1)
+				if err := release(); err != nil {
+					t.Errorf("release: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if overlaps > 0 {
+		t.Fatalf("%d acquisitions overlapped", overlaps)
+	}
+	// 200 toggles end where they started
+	assertFiles(t, dir)
+}
+
+func TestAcquireDropsIdleLocks(t *testing.T) {
+	store := NewStore(t.TempDir(), true)
+	var inside, overlaps int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				// Half the callers share one session, the rest use their own
+				id := "shared"
+				if i%2 == 1 {
+					id = fmt.Sprintf("session-%d-%d", i, j)
+				}
+				_, release := store.Acquire(id)
+				if id == "shared" && atomic.AddInt32(&inside, 1) > 1 {
+					atomic.AddInt32(&overlaps, 1)
+				}
+				time.Sleep(100 * time.Microsecond)
+				if id == "shared" {
+					atomic.AddInt32(&inside, -1)
+				}
+				if err := release(); err != nil {
+					t.Errorf("release: %v", err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if overlaps > 0 {
+		t.Fatalf("%d acquisitions of the shared session overlapped", overlaps)
+	}
+	if len(store.locks) != 0 {
+		t.Errorf("%d session locks left after every session was released", len(store.locks))
+	}
+}
+
+func assertFiles(t *testing.T, dir string, want ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	if len(got) != len(want) {
+		t.Fatalf("files in %s = %v, want %v", filepath.Base(dir), got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("files in %s = %v, want %v", filepath.Base(dir), got, want)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package shell
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Env follows what a command line changes as it runs: the working
+// directory (cd, pushd, popd) and the variables it assigns. Pass every
+// command from Parse to Step, in order, after looking at it; Dirs and
+// Expand then answer for the next command. Nothing is executed, so a
+// directory or value that depends on runtime output is unknown.
+type Env struct {
+	home    string
+	environ map[string]string
+	scopes  map[*Scope]*scopeState
+}
+
+type scopeState struct {
+	dirs      []string   // Candidate working directories; nil when unknown
+	stack     [][]string // pushd directory stack
+	vars      map[string]*string
+	afterPipe bool // The previous command piped into this one
+	// Where the shell still is if a cd in the current && chain failed
+	fallback     []string
+	fallbackLost bool
+}
+
+// NewEnv starts at dir with the environment of the calling shell
+func NewEnv(dir string, environ map[string]string) *Env {
+	e := &Env{home: environ["HOME"], environ: environ, scopes: map[*Scope]*scopeState{}}
+	top := &scopeState{vars: map[string]*string{}}
+	if dir != "" {
+		top.dirs = []string{filepath.Clean(dir)}
+	}
+	e.scopes[nil] = top
+	return e
+}
+
+// state returns a scope's state, starting a subshell from its parent's
+// state at the point it is first seen
+func (e *Env) state(scope *Scope) *scopeState {
+	if s, ok := e.scopes[scope]; ok {
+		return s
+	}
+	parent := e.state(scope.Parent)
+	s := &scopeState{dirs: parent.dirs, stack: parent.stack, vars: map[string]*string{}}
+	for name, value := range parent.vars {
+		s.vars[name] = value
+	}
+	e.scopes[scope] = s
+	return s
+}
+
+// Dirs returns the directories cmd may run in: more than one after a cd
+// that may have failed (cd dir; ...), and false when it can't be told
+// (cd -, cd "$(...)", popd with an empty stack)
+func (e *Env) Dirs(cmd Command) ([]string, bool) {
+	dirs := e.state(cmd.Scope).dirs
+	return dirs, dirs != nil
+}
+
+// Paths resolves a word naming a file against the directories cmd may run
+// in. It returns false when the word or the directory is not known.
+func (e *Env) Paths(cmd Command, word string) ([]string, bool) {
+	p, ok := e.Expand(cmd, word)
+	if !ok {
+		return nil, false
+	}
+	if filepath.IsAbs(p) {
+		return []string{filepath.Clean(p)}, true
+	}
+	dirs, ok := e.Dirs(cmd)
+	if !ok {
+		return nil, false
+	}
+	paths := make([]string, len(dirs))
+	for i, dir := range dirs {
+		paths[i] = filepath.Join(dir, p)
+	}
+	return paths, true
+}
+
+// Expand replaces a leading ~ and $NAME or ${NAME} with their values.
+// It returns false when anything else would be expanded at run time:
+// command substitution, backticks, ${NAME:-...} forms, positional or
+// special parameters, or a variable that is unset or set from output.
+func (e *Env) Expand(cmd Command, word string) (string, bool) {
+	if strings.ContainsRune(word, '`') {
+		return "", false
+	}
+	if word == "~" || strings.HasPrefix(word, "~/") {
+		if e.home == "" {
+			return "", false
+		}
+		word = e.home + word[1:]
+	}
+	var b strings.Builder
+	for i := 0; i < len(word); i++ {
+		if word[i] != '$' {
+			b.WriteByte(word[i])
+			continue
+		}
+		rest := word[i+1:]
+		var name string
+		if strings.HasPrefix(rest, "{") {
+			end := strings.IndexByte(rest, '}')
+			if end < 0 {
+				return "", false
+			}
+			name = rest[1:end]
+			i += end + 1
+		} else {
+			end := 0
+			for end < len(rest) && isNameByte(rest[end], end) {
+				end++
+			}
+			name = rest[:end]
+			i += end
+		}
+		if !validName(name) {
+			return "", false
+		}
+		value, ok := e.lookup(cmd, name)
+		if !ok {
+			return "", false
+		}
+		b.WriteString(value)
+	}
+	return b.String(), true
+}
+
+func (e *Env) lookup(cmd Command, name string) (string, bool) {
+	if value, ok := e.state(cmd.Scope).vars[name]; ok {
+		if value == nil {
+			return "", false
+		}
+		return *value, true
+	}
+	value, ok := e.environ[name]
+	return value, ok
+}
+
+// Step applies what cmd changes for the commands after it
+func (e *Env) Step(cmd Command) {
+	s := e.state(cmd.Scope)
+	// Pipeline members and background jobs run in subshells of their own
+	inSubshell := s.afterPipe || cmd.Separator == "|" || cmd.Separator == "&"
+	s.afterPipe = cmd.Separator == "|"
+	if !inSubshell {
+		e.apply(cmd, s)
+	}
+	if cmd.Separator != "&&" && cmd.Separator != "|" {
+		// The && chain ends here. If a cd in it failed, the rest of the
+		// chain was skipped and the shell is still where it was.
+		if s.fallbackLost {
+			s.dirs = nil
+		} else if s.dirs != nil && s.fallback != nil {
+			s.dirs = unique(append(append([]string{}, s.dirs...), s.fallback...))
+		}
+		s.fallback, s.fallbackLost = nil, false
+	}
+}
+
+func (e *Env) apply(cmd Command, s *scopeState) {
+	if len(cmd.Args) == 0 {
+		e.assign(cmd, s, cmd.Assignments)
+		return
+	}
+	switch cmd.Name() {
+	case "export", "declare", "typeset", "local", "readonly":
+		e.assign(cmd, s, cmd.Assignments)
+	case "unset":
+		for _, name := range cmd.Args[1:] {
+			empty := ""
+			s.vars[name] = &empty
+		}
+	case "read", "for", "select", "mapfile", "readarray", "getopts":
+		// Set from input or a list: unknown from here on
+		for _, arg := range cmd.Args[1:] {
+			if arg == "in" {
+				break
+			}
+			if validName(arg) {
+				s.vars[arg] = nil
+			}
+		}
+	case "cd":
+		target := "~"
+		if args := dirArgs(cmd.Args[1:]); len(args) > 0 {
+			target = args[0]
+		}
+		e.chdir(cmd, s, target)
+	case "pushd":
+		args := dirArgs(cmd.Args[1:])
+		if len(args) == 0 || strings.HasPrefix(args[0], "+") || args[0] == "-" {
+			s.dirs = nil
+			return
+		}
+		old := s.dirs
+		e.chdir(cmd, s, args[0])
+		s.stack = append(append([][]string{}, s.stack...), old)
+	case "popd":
+		if len(s.stack) == 0 || len(cmd.Args) > 1 {
+			s.dirs = nil
+			return
+		}
+		s.dirs = s.stack[len(s.stack)-1]
+		s.stack = s.stack[:len(s.stack)-1]
+	}
+}
+
+// dirArgs drops the options of cd and pushd (-L, -P, -e, -@, -n)
+func dirArgs(args []string) []string {
+	for len(args) > 0 && len(args[0]) > 1 && strings.HasPrefix(args[0], "-") {
+		if args[0] == "--" {
+			return args[1:]
+		}
+		args = args[1:]
+	}
+	return args
+}
+
+func (e *Env) assign(cmd Command, s *scopeState, assignments []Assignment) {
+	for _, a := range assignments {
+		if value, ok := e.Expand(cmd, a.Value); ok {
+			s.vars[a.Name] = &value
+		} else {
+			s.vars[a.Name] = nil
+		}
+	}
+}
+
+// chdir moves to target. Unless the next command only runs after a
+// successful cd (cd dir && ...), it may also still run where it was.
+func (e *Env) chdir(cmd Command, s *scopeState, target string) {
+	if target == "-" {
+		s.dirs = nil
+		return
+	}
+	paths, ok := e.Paths(cmd, target)
+	if !ok {
+		s.dirs = nil
+		return
+	}
+	switch {
+	case cmd.Separator == "&&":
+		s.fallback = append(s.fallback, s.dirs...)
+		s.fallbackLost = s.fallbackLost || s.dirs == nil
+	case s.dirs == nil:
+		paths = nil
+	default:
+		paths = append(paths, s.dirs...)
+	}
+	s.dirs = unique(paths)
+}
+
+func unique(list []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, item := range list {
+		if !seen[item] {
+			seen[item] = true
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func isNameByte(c byte, i int) bool {
+	return c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (i > 0 && c >= '0' && c <= '9')
+}
+
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isNameByte(name[i], i) {
+			return false
+		}
+	}
+	return true
+}
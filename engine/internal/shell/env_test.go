@@ -0,0 +1,93 @@
+package shell
+
+import (
+	"reflect"
+	"testing"
+)
+
+// lastDirs runs line through an Env and returns where its last command runs
+func lastDirs(line string) ([]string, bool) {
+	env := NewEnv("/work", map[string]string{"HOME": "/home/u", "TMPDIR": "/tmp"})
+	cmds := Parse(line)
+	for _, cmd := range cmds[:len(cmds)-1] {
+		env.Step(cmd)
+	}
+	return env.Dirs(cmds[len(cmds)-1])
+}
+
+func TestEnvDirs(t *testing.T) {
+	tests := []struct {
+		line  string
+		want  []string
+		known bool
+	}{
+		{"ls", []string{"/work"}, true},
+		{"cd sub && ls", []string{"/work/sub"}, true},
+		{"cd /a && cd b && ls", []string{"/a/b"}, true},
+		{"cd && ls", []string{"/home/u"}, true},
+		{"cd ~/x && ls", []string{"/home/u/x"}, true},
+		{"cd -P $TMPDIR && ls", []string{"/tmp"}, true},
+		{"D=/a; cd $D && ls", []string{"/a"}, true},
+		{"cd /a; ls", []string{"/a", "/work"}, true},
+		{"cd /a || ls", []string{"/a", "/work"}, true},
+		{"cd /a && true; ls", []string{"/a", "/work"}, true},
+		{"cd /a && true && ls", []string{"/a"}, true},
+		{"(cd /a && true); ls", []string{"/work"}, true},
+		{"(cd /a && ls)", []string{"/a"}, true},
+		{"cd /a | true; ls", []string{"/work"}, true},
+		{"true | cd /a; ls", []string{"/work"}, true},
+		{"cd /a & ls", []string{"/work"}, true},
+		{"echo $(cd /a) && ls", []string{"/work"}, true},
+		{"sh -c 'cd /a' && ls", []string{"/work"}, true},
+		{"pushd /a && pushd /b && popd && ls", []string{"/a"}, true},
+		{"pushd /a && popd && ls", []string{"/work"}, true},
+		{"cd - && ls", nil, false},
+		{"popd && ls", nil, false},
+		{"cd $(mktemp -d) && ls", nil, false},
+		{"cd $UNSET && ls", nil, false},
+		{"read D; cd /$D && ls", nil, false},
+	}
+	for _, tt := range tests {
+		got, known := lastDirs(tt.line)
+		if known != tt.known || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q runs last command in %q (known %v), want %q (known %v)", tt.line, got, known, tt.want, tt.known)
+		}
+	}
+}
+
+func TestEnvExpand(t *testing.T) {
+	env := NewEnv("/work", map[string]string{"HOME": "/home/u", "X": "x"})
+	for _, cmd := range Parse("A=/a; export B=$A/b; unset X") {
+		env.Step(cmd)
+	}
+	last := Parse("true")[0]
+	tests := []struct {
+		word string
+		want string
+		ok   bool
+	}{
+		{"plain", "plain", true},
+		{"~", "/home/u", true},
+		{"~/f", "/home/u/f", true},
+		{"$HOME/f", "/home/u/f", true},
+		{"${B}/c", "/a/b/c", true},
+		{"$A$A", "/a/a", true},
+		{"[$X]", "[]", true},
+		{"$(pwd)", "", false},
+		{"`pwd`", "", false},
+		{"${A:-/b}", "", false},
+		{"$1", "", false},
+		{"$NOPE", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := env.Expand(last, tt.word)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Expand(%q) = %q, %v, want %q, %v", tt.word, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	paths, ok := env.Paths(last, "rel")
+	if !ok || !reflect.DeepEqual(paths, []string{"/work/rel"}) {
+		t.Errorf("Paths(rel) = %v, %v, want [/work/rel]", paths, ok)
+	}
+}
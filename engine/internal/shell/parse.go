@@ -0,0 +1,457 @@
+package shell
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Redirect is a redirection attached to a command
+type Redirect struct {
+	Op     string // >, >>, >|, &>, &>>, <, <<, <<<, <>
+	FD     string // Explicit file descriptor, e.g. "2" in 2>file
+	Target string // File, heredoc delimiter or here-string word
+	Body   string // Heredoc content, one newline-terminated line per line
+}
+
+// Writes reports whether the redirection writes to its target file
+func (r Redirect) Writes() bool {
+	switch r.Op {
+	case ">", ">>", ">|", "&>", "&>>", "<>":
+		return true
+	}
+	return false
+}
+
+// Command is one simple command of a shell line
+type Command struct {
+	Assignments []Assignment // VAR=value prefixes and export/env arguments
+	Args        []string     // Words after quote removal
+	Redirects   []Redirect
+	Separator   string   // Operator that ended the command: ; && || | & or ""
+	Nested      bool     // Found inside $(...), `...` or sh -c
+	Wrappers    []string // Programs unwrap stripped, e.g. sudo or xargs
+	Scope       *Scope   // Shell the command runs in; nil for the top level
+}
+
+// Scope is a subshell: ( ... ), a command substitution or an sh -c script.
+// A cd or assignment inside it does not reach the commands after it.
+type Scope struct {
+	Parent *Scope
+}
+
+// Assignment is a VAR=value environment assignment
+type Assignment struct {
+	Name  string
+	Value string
+}
+
+// Name returns the base name of the program, or "" for pure assignments
+func (c Command) Name() string {
+	if len(c.Args) == 0 {
+		return ""
+	}
+	return filepath.Base(c.Args[0])
+}
+
+// Parse splits a shell command line into simple commands. It understands
+// quoting, escapes, control operators, redirections, heredocs, comments and
+// command substitution, which is parsed recursively. Wrappers such as env,
+// sudo, xargs and "sh -c" are unwrapped so the wrapped program is visible.
+// Parsing is best effort: it never expands variables or executes anything.
+func Parse(line string) []Command {
+	return parse(line, nil)
+}
+
+func parse(line string, scope *Scope) []Command {
+	p := &parser{src: line, scope: scope, base: scope, nested: scope != nil}
+	p.run()
+	return p.commands
+}
+
+type parser struct {
+	src      string
+	pos      int
+	commands []Command
+	current  Command
+	heredocs []heredoc
+	nested   bool
+	scope    *Scope // Current subshell
+	base     *Scope // Scope of the whole line; an unmatched ) stays in it
+}
+
+type heredoc struct {
+	delim    string
+	stripTab bool
+	redirect *Redirect // Receives the body once the line is read
+}
+
+type token struct {
+	kind  int // tokWord, tokOp
+	value string
+	fd    string
+}
+
+const (
+	tokWord = iota
+	tokOp
+)
+
+func (p *parser) run() {
+	var pendingRedirect *Redirect
+	for {
+		tok, ok := p.next()
+		if !ok {
+			break
+		}
+
+		if pendingRedirect != nil {
+			if tok.kind == tokWord {
+				pendingRedirect.Target = tok.value
+				if pendingRedirect.Op == "<<" || pendingRedirect.Op == "<<-" {
+					p.heredocs = append(p.heredocs, heredoc{delim: tok.value, stripTab: pendingRedirect.Op == "<<-"})
+					pendingRedirect.Op = "<<"
+				}
+				p.current.Redirects = append(p.current.Redirects, *pendingRedirect)
+				pendingRedirect = nil
+				continue
+			}
+			pendingRedirect = nil
+		}
+
+		if tok.kind == tokWord {
+			p.addWord(tok.value)
+			continue
+		}
+
+		switch tok.value {
+		case ">", ">>", ">|", "&>", "&>>", "<", "<<", "<<-", "<<<", "<>":
+			pendingRedirect = &Redirect{Op: tok.value, FD: tok.fd}
+		case ">&", "<&":
+			// fd duplication: 2>&1, >&2 or a file target (>&file)
+			next, ok := p.next()
+			if ok && next.kind == tokWord && !isFD(next.value) {
+				p.current.Redirects = append(p.current.Redirects, Redirect{Op: ">", FD: tok.fd, Target: next.value})
+			}
+		case "\n":
+			p.finish("")
+			p.skipHeredocs()
+		case "(":
+			p.finish("")
+			p.scope = &Scope{Parent: p.scope}
+		case ")":
+			p.finish("")
+			if p.scope != p.base {
+				p.scope = p.scope.Parent
+			}
+		case "{", "}":
+			p.finish("")
+		default: // ; && || | & ;;
+			p.finish(tok.value)
+		}
+	}
+	p.finish("")
+}
+
+func (p *parser) addWord(word string) {
+	// Leading VAR=value words are assignments, not arguments
+	if len(p.current.Args) == 0 {
+		if name, value, ok := splitAssignment(word); ok {
+			p.current.Assignments = append(p.current.Assignments, Assignment{Name: name, Value: value})
+			return
+		}
+		if isKeyword(word) {
+			return
+		}
+	}
+	p.current.Args = append(p.current.Args, word)
+}
+
+func (p *parser) finish(separator string) {
+	cmd := p.current
+	p.current = Command{}
+	if len(cmd.Args) == 0 && len(cmd.Assignments) == 0 && len(cmd.Redirects) == 0 {
+		return
+	}
+	cmd.Separator = separator
+	cmd.Nested = p.nested
+	cmd.Scope = p.scope
+	p.bindHeredocs(cmd.Redirects)
+	p.commands = append(p.commands, unwrap(cmd)...)
+}
+
+// bindHeredocs points pending heredocs at a finished command's redirects.
+// The redirects slice is final at this point and shared with the copies
+// unwrap makes, so bodies read later land in the recorded command.
+func (p *parser) bindHeredocs(redirects []Redirect) {
+	next := 0
+	for i := range redirects {
+		if redirects[i].Op != "<<" {
+			continue
+		}
+		for next < len(p.heredocs) && p.heredocs[next].redirect != nil {
+			next++
+		}
+		if next == len(p.heredocs) {
+			return
+		}
+		p.heredocs[next].redirect = &redirects[i]
+	}
+}
+
+// skipHeredocs consumes heredoc bodies that start after a newline
+func (p *parser) skipHeredocs() {
+	for len(p.heredocs) > 0 {
+		doc := p.heredocs[0]
+		p.heredocs = p.heredocs[1:]
+		var body strings.Builder
+		for p.pos < len(p.src) {
+			end := strings.IndexByte(p.src[p.pos:], '\n')
+			var line string
+			if end < 0 {
+				line = p.src[p.pos:]
+				p.pos = len(p.src)
+			} else {
+				line = p.src[p.pos : p.pos+end]
+				p.pos += end + 1
+			}
+			if doc.stripTab {
+				line = strings.TrimLeft(line, "\t")
+			}
+			if line == doc.delim {
+				break
+			}
+			body.WriteString(line + "\n")
+		}
+		if doc.redirect != nil {
+			doc.redirect.Body = body.String()
+		}
+	}
+}
+
+// next returns the next word or operator token
+func (p *parser) next() (token, bool) {
+	// Skip blanks, line continuations and comments
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == ' ' || c == '\t' || c == '\r' {
+			p.pos++
+			continue
+		}
+		if c == '\\' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '\n' {
+			p.pos += 2
+			continue
+		}
+		if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+			continue
+		}
+		break
+	}
+	if p.pos >= len(p.src) {
+		return token{}, false
+	}
+
+	// fd-prefixed redirection: 2>file, 1>>file, 2>&1
+	fdEnd := p.pos
+	for fdEnd < len(p.src) && p.src[fdEnd] >= '0' && p.src[fdEnd] <= '9' {
+		fdEnd++
+	}
+	if fdEnd > p.pos && fdEnd < len(p.src) && (p.src[fdEnd] == '>' || p.src[fdEnd] == '<') {
+		fd := p.src[p.pos:fdEnd]
+		p.pos = fdEnd
+		op := p.operator()
+		return token{kind: tokOp, value: op, fd: fd}, true
+	}
+
+	if op := p.operator(); op != "" {
+		return token{kind: tokOp, value: op}, true
+	}
+	return token{kind: tokWord, value: p.word()}, true
+}
+
+var operators = []string{
+	"&>>", "<<<", "<<-", ";;", "&&", "||", "&>", ">>", ">|", ">&", "<&", "<<", "<>",
+	">", "<", "|", "&", ";", "(", ")", "\n",
+}
+
+func (p *parser) operator() string {
+	rest := p.src[p.pos:]
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			p.pos += len(op)
+			return op
+		}
+	}
+	// Braces only group at the start of a word
+	if rest[0] == '{' || rest[0] == '}' {
+		if len(rest) == 1 || strings.ContainsRune(" \t\n;", rune(rest[1])) {
+			p.pos++
+			return rest[:1]
+		}
+	}
+	return ""
+}
+
+// word reads one word, removing quotes and escapes. Command substitutions
+// are kept in the word as written and parsed as nested commands.
+func (p *parser) word() string {
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case strings.IndexByte(" \t\r\n;&|<>()", c) >= 0:
+			return b.String()
+		case c == '\\':
+			if p.pos+1 < len(p.src) {
+				b.WriteByte(p.src[p.pos+1])
+				p.pos += 2
+			} else {
+				p.pos++
+			}
+		case c == '\'':
+			end := strings.IndexByte(p.src[p.pos+1:], '\'')
+			if end < 0 {
+				b.WriteString(p.src[p.pos+1:])
+				p.pos = len(p.src)
+			} else {
+				b.WriteString(p.src[p.pos+1 : p.pos+1+end])
+				p.pos += end + 2
+			}
+		case c == '$' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '\'':
+			p.pos++
+			end := strings.IndexByte(p.src[p.pos+1:], '\'')
+			if end < 0 {
+				b.WriteString(p.src[p.pos+1:])
+				p.pos = len(p.src)
+			} else {
+				b.WriteString(unescapeANSI(p.src[p.pos+1 : p.pos+1+end]))
+				p.pos += end + 2
+			}
+		case c == '"':
+			p.pos++
+			p.doubleQuoted(&b)
+		case c == '$' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '(':
+			b.WriteString(p.substitution())
+		case c == '`':
+			b.WriteString(p.backtick())
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return b.String()
+}
+
+func (p *parser) doubleQuoted(b *strings.Builder) {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return
+		case c == '\\' && p.pos+1 < len(p.src) && strings.IndexByte("\"\\$`\n", p.src[p.pos+1]) >= 0:
+			if p.src[p.pos+1] != '\n' {
+				b.WriteByte(p.src[p.pos+1])
+			}
+			p.pos += 2
+		case c == '$' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '(':
+			b.WriteString(p.substitution())
+		case c == '`':
+			b.WriteString(p.backtick())
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// substitution consumes $(...) and parses its body as nested commands
+func (p *parser) substitution() string {
+	start := p.pos
+	p.pos += 2
+	depth := 1
+	for p.pos < len(p.src) && depth > 0 {
+		switch p.src[p.pos] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '\\':
+			p.pos++
+		case '\'':
+			if end := strings.IndexByte(p.src[p.pos+1:], '\''); end >= 0 {
+				p.pos += end + 1
+			}
+		}
+		p.pos++
+	}
+	raw := p.src[start:p.pos]
+	body := strings.TrimSuffix(raw[2:], ")")
+	p.parseNested(body)
+	return raw
+}
+
+func (p *parser) backtick() string {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) && p.src[p.pos] != '`' {
+		if p.src[p.pos] == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+	p.pos++
+	if p.pos > len(p.src) {
+		p.pos = len(p.src)
+	}
+	raw := p.src[start:p.pos]
+	p.parseNested(strings.Trim(raw, "`"))
+	return raw
+}
+
+// parseNested records commands from a substitution ahead of the command
+// that contains it, which is the order the shell runs them in
+func (p *parser) parseNested(body string) {
+	p.commands = append(p.commands, parse(body, &Scope{Parent: p.scope})...)
+}
+
+func splitAssignment(word string) (string, string, bool) {
+	eq := strings.IndexByte(word, '=')
+	if eq <= 0 {
+		return "", "", false
+	}
+	name := word[:eq]
+	for i, c := range name {
+		if !(c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (i > 0 && c >= '0' && c <= '9')) {
+			return "", "", false
+		}
+	}
+	return name, word[eq+1:], true
+}
+
+func isKeyword(word string) bool {
+	switch word {
+	case "if", "then", "else", "elif", "fi", "do", "done", "while", "until", "!", "time", "{", "}":
+		return true
+	}
+	return false
+}
+
+func isFD(word string) bool {
+	if word == "-" {
+		return true
+	}
+	for _, c := range word {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return word != ""
+}
+
+func unescapeANSI(s string) string {
+	replacer := strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\\`, `\`, `\'`, "'", `\"`, `"`)
+	return replacer.Replace(s)
+}
@@ -0,0 +1,114 @@
+package shell
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line string
+		want [][]string // Args of each command, in order
+	}{
+		{"ls -la", [][]string{{"ls", "-la"}}},
+		{"a && b || c; d | e & f", [][]string{{"a"}, {"b"}, {"c"}, {"d"}, {"e"}, {"f"}}},
+		{`echo 'single $x' "double \"q\"" esc\ aped`, [][]string{{"echo", "single $x", `double "q"`, "esc aped"}}},
+		{`printf $'a\tb'`, [][]string{{"printf", "a\tb"}}},
+		{"echo hi # comment\nls", [][]string{{"echo", "hi"}, {"ls"}}},
+		{"echo a \\\n  b", [][]string{{"echo", "a", "b"}}},
+		{"if true; then rm x; fi", [][]string{{"true"}, {"rm", "x"}}},
+		{"{ cd a; ls; }", [][]string{{"cd", "a"}, {"ls"}}},
+		{"echo $(cat a) b", [][]string{{"cat", "a"}, {"echo", "$(cat a)", "b"}}},
+		{"echo `cat a`", [][]string{{"cat", "a"}, {"echo", "`cat a`"}}},
+		{"sudo -u root rm x", [][]string{{"rm", "x"}}},
+		{"env -u X A=1 rm x", [][]string{{"rm", "x"}}},
+		{"timeout -s KILL 5 rm x", [][]string{{"rm", "x"}}},
+		{"xargs -n 1 rm", [][]string{{"rm"}}},
+		{"nice -n 5 nohup rm x", [][]string{{"rm", "x"}}},
+		{"bash -c 'cd a && rm b'", [][]string{{"cd", "a"}, {"rm", "b"}, {"bash", "-c", "cd a && rm b"}}},
+		{"sh -ec 'rm b'", [][]string{{"rm", "b"}, {"sh", "-ec", "rm b"}}},
+	}
+	for _, tt := range tests {
+		var got [][]string
+		for _, cmd := range Parse(tt.line) {
+			got = append(got, cmd.Args)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseAssignments(t *testing.T) {
+	cmds := Parse("A=1 B='two words' make; export C=3 D; env E=5 rm x")
+	want := [][]Assignment{
+		{{"A", "1"}, {"B", "two words"}},
+		{{"C", "3"}},
+		{{"E", "5"}},
+	}
+	if len(cmds) != len(want) {
+		t.Fatalf("got %d commands, want %d", len(cmds), len(want))
+	}
+	for i, cmd := range cmds {
+		if !reflect.DeepEqual(cmd.Assignments, want[i]) {
+			t.Errorf("command %d assignments = %v, want %v", i, cmd.Assignments, want[i])
+		}
+	}
+	if cmds[0].Name() != "make" || cmds[2].Name() != "rm" {
+		t.Errorf("names = %q, %q, want make, rm", cmds[0].Name(), cmds[2].Name())
+	}
+}
+
+func TestParseRedirects(t *testing.T) {
+	tests := []struct {
+		line string
+		want []Redirect
+	}{
+		{"echo x > out", []Redirect{{Op: ">", Target: "out"}}},
+		{"echo x >> 'my log'", []Redirect{{Op: ">>", Target: "my log"}}},
+		{"cmd 2> err 2>&1", []Redirect{{Op: ">", FD: "2", Target: "err"}}},
+		{"cmd &> all", []Redirect{{Op: "&>", Target: "all"}}},
+		{"cmd >&file", []Redirect{{Op: ">", Target: "file"}}},
+		{"cat < in", []Redirect{{Op: "<", Target: "in"}}},
+		{"cat <<< 'here string'", []Redirect{{Op: "<<<", Target: "here string"}}},
+		{"cat > f <<EOF\na\n  b\nEOF\nls", []Redirect{{Op: ">", Target: "f"}, {Op: "<<", Target: "EOF", Body: "a\n  b\n"}}},
+		{"cat <<-'EOF'\n\tindented\n\tEOF", []Redirect{{Op: "<<", Target: "EOF", Body: "indented\n"}}},
+	}
+	for _, tt := range tests {
+		cmds := Parse(tt.line)
+		if len(cmds) == 0 {
+			t.Errorf("Parse(%q) found no commands", tt.line)
+			continue
+		}
+		if !reflect.DeepEqual(cmds[0].Redirects, tt.want) {
+			t.Errorf("Parse(%q) redirects = %+v, want %+v", tt.line, cmds[0].Redirects, tt.want)
+		}
+	}
+	if cmds := Parse("cat <<EOF\nx\nEOF\nls"); len(cmds) != 2 || cmds[1].Name() != "ls" {
+		t.Errorf("heredoc body was parsed as commands: %+v", cmds)
+	}
+}
+
+func TestParseStructure(t *testing.T) {
+	cmds := Parse("sudo xargs rm; a | b; (c; d); e $(f)")
+	byName := map[string]Command{}
+	for _, cmd := range cmds {
+		byName[cmd.Name()] = cmd
+	}
+	if got := byName["rm"].Wrappers; !reflect.DeepEqual(got, []string{"sudo", "xargs"}) {
+		t.Errorf("rm wrappers = %v, want [sudo xargs]", got)
+	}
+	if byName["a"].Separator != "|" || byName["b"].Separator != ";" {
+		t.Errorf("separators = %q, %q, want | and ;", byName["a"].Separator, byName["b"].Separator)
+	}
+	c, d, e, f := byName["c"], byName["d"], byName["e"], byName["f"]
+	if c.Scope == nil || c.Scope != d.Scope {
+		t.Errorf("( c; d ) are not in one subshell: %p %p", c.Scope, d.Scope)
+	}
+	if e.Scope != nil || e.Nested {
+		t.Errorf("e is not at the top level")
+	}
+	if f.Scope == nil || f.Scope == c.Scope || !f.Nested || f.Scope.Parent != nil {
+		t.Errorf("$(f) is not its own subshell of the top level")
+	}
+}
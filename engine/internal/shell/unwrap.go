@@ -0,0 +1,97 @@
+package shell
+
+import "strings"
+
+// unwrap strips command wrappers so the program that actually runs is the
+// command's name. export/declare arguments become assignments, and the
+// script passed to "sh -c" is parsed as nested commands.
+func unwrap(cmd Command) []Command {
+	var extra []Command
+
+	for len(cmd.Args) > 0 {
+		name := cmd.Name()
+		switch name {
+		case "env", "sudo", "doas", "command", "builtin", "exec", "nohup", "stdbuf", "setsid",
+			"nice", "ionice", "timeout", "xargs":
+			cmd.Wrappers = append(cmd.Wrappers, name)
+		}
+		switch name {
+		case "export", "declare", "typeset", "local", "readonly":
+			for _, arg := range cmd.Args[1:] {
+				if name, value, ok := splitAssignment(arg); ok {
+					cmd.Assignments = append(cmd.Assignments, Assignment{Name: name, Value: value})
+				}
+			}
+			return append(extra, cmd)
+
+		case "env":
+			args := cmd.Args[1:]
+			for len(args) > 0 {
+				if name, value, ok := splitAssignment(args[0]); ok {
+					cmd.Assignments = append(cmd.Assignments, Assignment{Name: name, Value: value})
+				} else if args[0] == "-u" || args[0] == "-C" || args[0] == "-S" {
+					if len(args) > 1 {
+						args = args[1:]
+					}
+				} else if !strings.HasPrefix(args[0], "-") {
+					break
+				}
+				args = args[1:]
+			}
+			cmd.Args = args
+
+		case "sudo", "doas":
+			cmd.Args = skipFlags(cmd.Args[1:], "-u", "-g", "-C", "-h", "-p")
+
+		case "command", "builtin", "exec", "nohup", "stdbuf", "setsid":
+			cmd.Args = skipFlags(cmd.Args[1:], "-a")
+
+		case "nice", "ionice":
+			cmd.Args = skipFlags(cmd.Args[1:], "-n", "-c")
+
+		case "timeout":
+			args := skipFlags(cmd.Args[1:], "-s", "-k", "--signal", "--kill-after")
+			if len(args) > 0 {
+				args = args[1:] // duration
+			}
+			cmd.Args = args
+
+		case "xargs":
+			cmd.Args = skipFlags(cmd.Args[1:], "-I", "-n", "-P", "-L", "-d", "-E", "-s", "-a")
+
+		case "sh", "bash", "zsh", "dash", "ksh":
+			for i, arg := range cmd.Args[1:] {
+				if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.Contains(arg, "c") && i+2 < len(cmd.Args) {
+					extra = append(extra, parse(cmd.Args[i+2], &Scope{Parent: cmd.Scope})...)
+					break
+				}
+			}
+			return append(extra, cmd)
+
+		default:
+			return append(extra, cmd)
+		}
+	}
+	return append(extra, cmd)
+}
+
+// skipFlags drops leading options, including the values of options that
+// take one
+func skipFlags(args []string, withValue ...string) []string {
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		if args[0] == "--" {
+			return args[1:]
+		}
+		takesValue := false
+		for _, flag := range withValue {
+			if args[0] == flag {
+				takesValue = true
+			}
+		}
+		args = args[1:]
+		if takesValue && len(args) > 0 {
+			args = args[1:]
+		}
+	}
+	return args
+}
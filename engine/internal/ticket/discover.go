@@ -0,0 +1,128 @@
+package ticket
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Dir returns the tickets directory of a project
+func Dir(root string) string {
+	return filepath.Join(root, "tickets")
+}
+
+// Discover reads every ticket under root/tickets: queue/TICKET-*.md and
+// <location>/<dir>/TICKET-*.md. TEMPLATE.md and archive/ are not tickets.
+// Files that can't be read are reported in the joined error; the tickets
+// that could are still returned, ordered by path.
+func Discover(root string) ([]*Ticket, error) {
+	var tickets []*Ticket
+	var errs []error
+	for _, location := range Locations {
+		dir := filepath.Join(Dir(root), location)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if !entry.IsDir() {
+				if IsTicketFile(entry.Name()) {
+					tickets, errs = load(tickets, errs, path)
+				}
+				continue
+			}
+			files, err := os.ReadDir(path)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			for _, file := range files {
+				if !file.IsDir() && IsTicketFile(file.Name()) {
+					tickets, errs = load(tickets, errs, filepath.Join(path, file.Name()))
+				}
+			}
+		}
+	}
+	sort.Slice(tickets, func(i, j int) bool { return tickets[i].Path < tickets[j].Path })
+	return tickets, errors.Join(errs...)
+}
+
+func load(tickets []*Ticket, errs []error, path string) ([]*Ticket, []error) {
+	t, err := Load(path)
+	if err != nil {
+		return tickets, append(errs, err)
+	}
+	return append(tickets, t), errs
+}
+
+// IsTicketFile reports whether a file name looks like a ticket (TICKET-*.md)
+func IsTicketFile(name string) bool {
+	return strings.HasPrefix(name, "TICKET-") && strings.HasSuffix(name, ".md")
+}
+
+// SessionForBranch returns the session id a branch works on:
+// ticket/<session-id> gives <session-id>, other branches their name with
+// slashes turned into dashes
+func SessionForBranch(branch string) string {
+	return strings.ReplaceAll(strings.TrimPrefix(branch, "ticket/"), "/", "-")
+}
+
+// ForSession finds the current ticket of a session: the highest sequence
+// in active/<session>, else in completed/<session>, else the queued one.
+// It returns nil when the session has no ticket.
+func ForSession(root, session string) (*Ticket, error) {
+	if session == "" {
+		return nil, nil
+	}
+	for _, location := range []string{Active, Completed, Queue} {
+		dir := filepath.Join(Dir(root), location)
+		paths, err := filepath.Glob(filepath.Join(dir, session, "TICKET-*.md"))
+		if err != nil {
+			return nil, err
+		}
+		if location == Queue {
+			if path := filepath.Join(dir, Name(session, 0)); fileExists(path) {
+				paths = append(paths, path)
+			}
+		}
+		if path := latest(paths, location); path != "" {
+			return Load(path)
+		}
+	}
+	return nil, nil
+}
+
+// ForBranch finds the ticket for a branch (see SessionForBranch)
+func ForBranch(root, branch string) (*Ticket, error) {
+	if branch == "" {
+		return nil, nil
+	}
+	return ForSession(root, SessionForBranch(branch))
+}
+
+// latest returns the path with the highest sequence among tickets of one
+// location, preferring sequenced names over queue names
+func latest(paths []string, location string) string {
+	best, bestSeq := "", -1
+	for _, path := range paths {
+		seq := 0
+		if _, sequence, ok := NameParts(filepath.Base(path), location); ok {
+			seq, _ = SequenceNumber(sequence)
+		}
+		if seq > bestSeq {
+			best, bestSeq = path, seq
+		}
+	}
+	return best
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
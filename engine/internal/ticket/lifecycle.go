@@ -0,0 +1,685 @@
+package ticket
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/gitstate"
+)
+
+// Options control the lifecycle commands
+type Options struct {
+	Base         string // Branch tickets are claimed on and merged into
+	WorktreeBase string // Worktrees go in WorktreeBase/<project>/<session-id>
+	Project      string // Project directory under WorktreeBase, default the repository name
+	// Push syncs with origin (pull, push, remote branch deletion). Without
+	// it, or without an origin remote, every step stays local.
+	Push bool
+	User string // Recorded as claimed_by
+	Now  time.Time
+}
+
+// DefaultOptions returns the options the lifecycle scripts used: base
+// main, worktrees under $WORKTREE_BASE (~/.novacloud/worktrees) and push
+func DefaultOptions() Options {
+	base := os.Getenv("WORKTREE_BASE")
+	if base == "" {
+		base = "~/.novacloud/worktrees"
+	}
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	return Options{Base: "main", WorktreeBase: base, Push: true, User: name, Now: time.Now()}
+}
+
+// Step is one change a lifecycle command makes
+type Step struct {
+	Description string
+	run         func() error
+}
+
+// Plan is what a lifecycle command will do. Planning only reads the
+// repository, so a plan can be printed for a dry run; any refusal (ticket
+// in the wrong place, branch not merged, ...) happens before a step runs.
+type Plan struct {
+	Action   string // activate, complete or cleanup
+	TicketID string
+	Branch   string
+	Worktree string
+	Notes    []string // Remarks such as skipped remote steps
+	Steps    []Step
+}
+
+func (p *Plan) add(description string, run func() error) {
+	p.Steps = append(p.Steps, Step{Description: description, run: run})
+}
+
+// Execute runs the steps in order and stops at the first failure. It
+// returns how many steps completed, so callers can tell a failure that
+// changed nothing from one that left earlier steps applied.
+func (p *Plan) Execute(report func(step Step, err error)) (int, error) {
+	for i, step := range p.Steps {
+		err := step.run()
+		if report != nil {
+			report(step, err)
+		}
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(p.Steps), nil
+}
+
+// PlanActivate claims a queued ticket and opens its worktree, as
+// activate-ticket.sh did: the ticket moves from tickets/queue/ to
+// tickets/active/<session-id>/ with the next sequence and status claimed,
+// is committed on the base branch (pushed, when pushing, as the lock),
+// then a ticket/<session-id> worktree is created and the ticket set to
+// in_progress there.
+func PlanActivate(path string, opts Options) (*Plan, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	t, err := Load(abs)
+	if err != nil {
+		return nil, err
+	}
+	name := filepath.Base(abs)
+	session, _, ok := NameParts(name, Queue)
+	if t.Location != Queue || t.Dir != "" || !ok {
+		return nil, fmt.Errorf("%s is not a queued ticket (expected tickets/queue/TICKET-<session-id>.md)", path)
+	}
+	if t.SessionID != "" {
+		session = t.SessionID
+	}
+
+	state, ok := gitstate.Read(filepath.Dir(abs))
+	if !ok {
+		return nil, fmt.Errorf("%s is not in a git repository", path)
+	}
+	main := state.MainRoot
+	if !sameDir(filepath.Dir(abs), filepath.Join(Dir(main), Queue)) {
+		return nil, fmt.Errorf("%s is not in the main repository's tickets/queue/ (%s)", path, main)
+	}
+	if branch, _ := gitstate.Git(main, "symbolic-ref", "--quiet", "--short", "HEAD"); branch != opts.Base {
+		return nil, fmt.Errorf("the main repository is on %q; tickets are claimed on %s", branch, opts.Base)
+	}
+
+	seq, err := NextSequence(main, session)
+	if err != nil {
+		return nil, err
+	}
+	project := opts.Project
+	if project == "" {
+		project = state.Project
+	}
+	plan := &Plan{
+		Action:   "activate",
+		TicketID: strings.TrimSuffix(Name(session, seq), ".md"),
+		Branch:   "ticket/" + session,
+		Worktree: filepath.Join(expandHome(opts.WorktreeBase), project, session),
+	}
+	if branchExists(main, plan.Branch) {
+		return nil, fmt.Errorf("branch %s already exists", plan.Branch)
+	}
+	if _, err := os.Stat(plan.Worktree); err == nil {
+		return nil, fmt.Errorf("worktree %s already exists", plan.Worktree)
+	}
+	push := plan.remote(main, opts.Push)
+
+	queueRel := relPath(main, abs)
+	activeRel := filepath.Join("tickets", Active, session, Name(session, seq))
+	claimed := []string{activeRel}
+	if tracked(main, queueRel) {
+		claimed = append(claimed, queueRel)
+	}
+
+	if push {
+		plan.add("pull "+opts.Base+" from origin", func() error {
+			_, err := runGit(main, "pull", "--ff-only", "origin", opts.Base)
+			return err
+		})
+	}
+	plan.add(fmt.Sprintf("move %s to %s (status: claimed)", queueRel, activeRel), func() error {
+		// The pull may have brought a claim or a later sequence
+		if next, err := NextSequence(main, session); err != nil || next != seq {
+			return fmt.Errorf("sequence for %s changed to %03d; run activate again", session, next)
+		}
+		data, err := os.ReadFile(abs)
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%s is no longer in the queue: it was claimed by someone else", queueRel)
+		}
+		if err != nil {
+			return err
+		}
+		data, err = updateFrontmatter(data,
+			field{key: "ticket_id", value: plan.TicketID},
+			field{key: "sequence", value: fmt.Sprintf("%03d", seq)},
+			field{key: "status", value: "claimed"},
+			field{key: "claimed_by", value: opts.User, after: "status", keep: true},
+			field{key: "claimed_at", value: opts.Now.Format("2006-01-02 15:04"), after: "claimed_by", keep: true},
+		)
+		if err != nil {
+			return err
+		}
+		return move(abs, filepath.Join(main, activeRel), data)
+	})
+	plan.add(fmt.Sprintf("commit \"claim: %s\" on %s", plan.TicketID, opts.Base), func() error {
+		return commit(main, "claim: "+plan.TicketID, claimed...)
+	})
+	if push {
+		plan.add("push "+opts.Base+" to origin (the claim lock)", func() error {
+			if _, err := runGit(main, "push", "origin", opts.Base); err != nil {
+				// Lost the race: drop the claim so the queue is untouched
+				if _, resetErr := runGit(main, "reset", "--keep", "HEAD~1"); resetErr != nil {
+					return fmt.Errorf("%v; undoing the claim commit also failed: %v", err, resetErr)
+				}
+				return fmt.Errorf("%v; claim commit undone, another developer may have claimed %s", err, session)
+			}
+			return nil
+		})
+	}
+	plan.add(fmt.Sprintf("create worktree %s on new branch %s from %s", plan.Worktree, plan.Branch, opts.Base), func() error {
+		if err := os.MkdirAll(filepath.Dir(plan.Worktree), 0755); err != nil {
+			return err
+		}
+		_, err := runGit(main, "worktree", "add", plan.Worktree, "-b", plan.Branch, opts.Base)
+		return err
+	})
+	plan.add("set worktree_path and status: in_progress in the worktree's ticket", func() error {
+		path := filepath.Join(plan.Worktree, activeRel)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		data, err = updateFrontmatter(data,
+			field{key: "worktree_path", value: plan.Worktree},
+			field{key: "status", value: "in_progress"},
+		)
+		if err != nil {
+			return err
+		}
+		data = appendChangelog(data, opts.Now, "Creator: activated",
+			"Worktree: "+plan.Worktree, "Branch: "+plan.Branch)
+		return writeAtomic(path, data)
+	})
+	plan.add(fmt.Sprintf("commit \"activate: %s\" on %s", plan.TicketID, plan.Branch), func() error {
+		return commit(plan.Worktree, "activate: "+plan.TicketID, activeRel)
+	})
+	if push {
+		plan.add("push "+plan.Branch+" to origin", func() error {
+			_, err := runGit(plan.Worktree, "push", "-u", "origin", plan.Branch)
+			return err
+		})
+	}
+	return plan, nil
+}
+
+// PlanComplete approves the active ticket of the worktree containing dir,
+// as complete-ticket.sh did: status approved, a changelog entry, and a
+// move to tickets/completed/<session-id>/ committed on the branch. path
+// names the ticket explicitly; by default it is the branch's ticket.
+func PlanComplete(dir, path string, opts Options) (*Plan, error) {
+	state, ok := gitstate.Read(dir)
+	if !ok {
+		return nil, fmt.Errorf("%s is not in a git repository", dir)
+	}
+	if !state.Worktree {
+		return nil, fmt.Errorf("run complete from the ticket's worktree, not the main repository (see git worktree list)")
+	}
+	if state.Branch == "" {
+		return nil, fmt.Errorf("HEAD is detached; check out the ticket branch")
+	}
+
+	var t *Ticket
+	var err error
+	if path != "" {
+		if path, err = filepath.Abs(path); err == nil {
+			t, err = Load(path)
+		}
+	} else {
+		t, err = ForBranch(state.Root, state.Branch)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t == nil || t.Location != Active || t.Dir == "" {
+		return nil, fmt.Errorf("no active ticket for branch %s (expected tickets/%s/%s/TICKET-*.md)",
+			state.Branch, Active, SessionForBranch(state.Branch))
+	}
+	name := filepath.Base(t.Path)
+	if _, _, ok := NameParts(name, Active); !ok {
+		return nil, fmt.Errorf("%s is not a sequenced ticket name (TICKET-<session-id>-NNN.md)", name)
+	}
+
+	plan := &Plan{
+		Action:   "complete",
+		TicketID: strings.TrimSuffix(name, ".md"),
+		Branch:   state.Branch,
+		Worktree: state.Root,
+	}
+	push := plan.remote(state.Root, opts.Push)
+	activeRel := relPath(state.Root, t.Path)
+	completedRel := filepath.Join("tickets", Completed, t.Dir, name)
+
+	plan.add(fmt.Sprintf("move %s to %s (status: approved)", activeRel, completedRel), func() error {
+		data, err := os.ReadFile(t.Path)
+		if err != nil {
+			return err
+		}
+		if data, err = updateFrontmatter(data, field{key: "status", value: "approved"}); err != nil {
+			return err
+		}
+		data = appendChangelog(data, opts.Now, "Creator: completed",
+			"Status changed to approved", "Ready for PR creation")
+		if err := move(t.Path, filepath.Join(state.Root, completedRel), data); err != nil {
+			return err
+		}
+		// Only removes the directory when it is empty
+		os.Remove(filepath.Dir(t.Path))
+		return nil
+	})
+	plan.add(fmt.Sprintf("commit \"complete: %s\" on %s", plan.TicketID, plan.Branch), func() error {
+		return commit(state.Root, "complete: "+plan.TicketID, activeRel, completedRel)
+	})
+	if push {
+		plan.add("push "+plan.Branch+" to origin", func() error {
+			_, err := runGit(state.Root, "push", "origin", plan.Branch)
+			return err
+		})
+	}
+	return plan, nil
+}
+
+// PlanCleanup removes the worktree and branches of a merged ticket, as
+// cleanup-merged-ticket.sh did, but decides "merged" from git alone: the
+// branch is an ancestor of the base branch, or (after a squash merge) the
+// branch's latest ticket in tickets/completed/<session-id>/ is on the base
+// branch. Protected branches and worktrees outside WorktreeBase are
+// refused.
+func PlanCleanup(dir, branch string, opts Options) (*Plan, error) {
+	if IsProtectedBranch(branch) || branch == opts.Base {
+		return nil, fmt.Errorf("refusing to clean up protected branch %s", branch)
+	}
+	state, ok := gitstate.Read(dir)
+	if !ok {
+		return nil, fmt.Errorf("%s is not in a git repository", dir)
+	}
+	main := state.MainRoot
+	plan := &Plan{Action: "cleanup", Branch: branch}
+	push := plan.remote(main, opts.Push)
+
+	base := opts.Base
+	if push {
+		if _, err := runGit(main, "fetch", "--quiet", "origin", opts.Base); err != nil {
+			plan.Notes = append(plan.Notes, "could not fetch origin/"+opts.Base+"; checking the local copy")
+		} else {
+			base = "origin/" + opts.Base
+		}
+	}
+	localBranch := branchExists(main, branch)
+	ref := branch
+	if !localBranch {
+		ref = "origin/" + branch
+	}
+	squashed := false
+	if _, err := runGit(main, "rev-parse", "--verify", "--quiet", ref); err == nil {
+		completed := completedTicket(main, ref, SessionForBranch(branch))
+		switch {
+		case isAncestor(main, ref, base):
+		case completed != "" && fileAt(main, base, completed):
+			squashed = true
+		case completed == "":
+			return nil, fmt.Errorf("%s is not merged into %s and has no ticket in tickets/%s/", branch, base, Completed)
+		default:
+			return nil, fmt.Errorf("%s is not merged into %s: %s is not on %s", branch, base, completed, base)
+		}
+	}
+
+	if worktree := worktreeFor(main, branch); worktree != "" {
+		if !insideDir(worktree, expandHome(opts.WorktreeBase)) {
+			return nil, fmt.Errorf("worktree %s is outside %s; remove it by hand", worktree, opts.WorktreeBase)
+		}
+		plan.Worktree = worktree
+		plan.add("remove worktree "+worktree, func() error {
+			_, err := runGit(main, "worktree", "remove", worktree)
+			return err
+		})
+	} else {
+		plan.Notes = append(plan.Notes, "no worktree for "+branch)
+	}
+	if localBranch {
+		// A squash merge leaves the branch unmerged as far as git knows;
+		// the completed ticket on the base branch is the proof instead
+		flag, how := "-d", ""
+		if squashed {
+			flag, how = "-D", " (squash merged)"
+		}
+		plan.add("delete local branch "+branch+how, func() error {
+			_, err := runGit(main, "branch", flag, branch)
+			return err
+		})
+	} else {
+		plan.Notes = append(plan.Notes, "no local branch "+branch)
+	}
+	if push {
+		if _, err := runGit(main, "ls-remote", "--exit-code", "--heads", "origin", branch); err == nil {
+			plan.add("delete origin/"+branch, func() error {
+				_, err := runGit(main, "push", "origin", "--delete", branch)
+				return err
+			})
+		}
+	}
+	plan.add("prune stale worktree references", func() error {
+		_, err := runGit(main, "worktree", "prune")
+		return err
+	})
+	return plan, nil
+}
+
+// IsProtectedBranch reports whether branch, with or without its ticket/
+// prefix, is listed in CLAUDE_PROTECTED_BRANCHES (comma or space
+// separated; main, master and production by default). Case is ignored.
+func IsProtectedBranch(branch string) bool {
+	list := os.Getenv("CLAUDE_PROTECTED_BRANCHES")
+	if strings.TrimSpace(list) == "" {
+		list = "main,master,production"
+	}
+	separator := func(r rune) bool { return r == ',' || unicode.IsSpace(r) }
+	for _, protected := range strings.FieldsFunc(list, separator) {
+		if strings.EqualFold(branch, protected) ||
+			strings.EqualFold(strings.TrimPrefix(branch, "ticket/"), protected) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextSequence returns the sequence a session's next ticket gets: one
+// past the highest in its active and completed directories
+func NextSequence(root, session string) (int, error) {
+	highest := 0
+	for _, location := range []string{Active, Completed} {
+		paths, err := filepath.Glob(filepath.Join(Dir(root), location, session, "TICKET-*.md"))
+		if err != nil {
+			return 0, err
+		}
+		for _, path := range paths {
+			if _, sequence, ok := NameParts(filepath.Base(path), location); ok {
+				if n, ok := SequenceNumber(sequence); ok && n > highest {
+					highest = n
+				}
+			}
+		}
+	}
+	return highest + 1, nil
+}
+
+// remote reports whether a plan syncs with origin, noting why not
+func (p *Plan) remote(dir string, push bool) bool {
+	if !push {
+		p.Notes = append(p.Notes, "not syncing with origin (--no-push)")
+		return false
+	}
+	if _, err := runGit(dir, "remote", "get-url", "origin"); err != nil {
+		p.Notes = append(p.Notes, "no origin remote: all steps are local")
+		return false
+	}
+	return true
+}
+
+// field is a frontmatter value to set. A missing key is added after the
+// line of after (or at the end); keep leaves an existing value alone.
+type field struct {
+	key, value, after string
+	keep              bool
+}
+
+// updateFrontmatter rewrites frontmatter lines in place, leaving the rest
+// of the file byte for byte
+func updateFrontmatter(data []byte, fields ...field) ([]byte, error) {
+	lines := strings.SplitAfter(string(data), "\n")
+	start, end := frontmatterBounds(lines)
+	if start < 0 {
+		return nil, fmt.Errorf("ticket has no frontmatter")
+	}
+	for _, f := range fields {
+		line := f.key + ": " + f.value + "\n"
+		index := findKey(lines[start:end], f.key)
+		if index >= 0 {
+			if !f.keep {
+				lines[start+index] = line
+			}
+			continue
+		}
+		at := end
+		if after := findKey(lines[start:end], f.after); f.after != "" && after >= 0 {
+			at = start + after + 1
+		}
+		lines = append(lines[:at], append([]string{line}, lines[at:]...)...)
+		end++
+	}
+	return []byte(strings.Join(lines, "")), nil
+}
+
+// frontmatterBounds returns the lines between the --- markers, skipping a
+// leading HTML comment as Parse does; start is -1 without frontmatter
+func frontmatterBounds(lines []string) (int, int) {
+	inComment := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case inComment:
+			inComment = !strings.Contains(line, "-->")
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "<!--"):
+			inComment = !strings.Contains(trimmed, "-->")
+		case trimmed == "---":
+			for j := i + 1; j < len(lines); j++ {
+				if strings.TrimSpace(lines[j]) == "---" {
+					return i + 1, j
+				}
+			}
+			return -1, -1
+		default:
+			return -1, -1
+		}
+	}
+	return -1, -1
+}
+
+func findKey(lines []string, key string) int {
+	if key == "" {
+		return -1
+	}
+	for i, line := range lines {
+		if strings.HasPrefix(line, key+":") {
+			return i
+		}
+	}
+	return -1
+}
+
+// appendChangelog adds a "## [time] - heading" entry at the end of a ticket
+func appendChangelog(data []byte, now time.Time, heading string, items ...string) []byte {
+	var b bytes.Buffer
+	b.Write(data)
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		b.WriteByte('\n')
+	}
+	fmt.Fprintf(&b, "\n## [%s] - %s\n", now.Format("2006-01-02 15:04"), heading)
+	for _, item := range items {
+		fmt.Fprintf(&b, "- %s\n", item)
+	}
+	return b.Bytes()
+}
+
+// writeAtomic replaces path through a temporary file in the same directory,
+// so readers see the old ticket or the new one, never a partial write
+func writeAtomic(path string, data []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".ticket-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// move writes the updated ticket to dst, then removes src. The source is
+// untouched until the destination is complete.
+func move(src, dst string, data []byte) error {
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("%s already exists", dst)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := writeAtomic(dst, data); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// commit records exactly the given paths, leaving anything else staged in
+// the repository out of the commit
+func commit(dir, message string, paths ...string) error {
+	args := append([]string{"add", "-A", "--"}, paths...)
+	if _, err := runGit(dir, args...); err != nil {
+		return err
+	}
+	args = append([]string{"commit", "--quiet", "-m", message, "--"}, paths...)
+	_, err := runGit(dir, args...)
+	return err
+}
+
+// runGit is gitstate.Git with git's own error message in the error
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if detail := strings.TrimSpace(stderr.String()); detail != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], detail)
+		}
+		return "", fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func branchExists(dir, branch string) bool {
+	_, err := runGit(dir, "show-ref", "--verify", "--quiet", "refs/heads/"+branch)
+	return err == nil
+}
+
+func tracked(dir, path string) bool {
+	_, err := runGit(dir, "ls-files", "--error-unmatch", "--", path)
+	return err == nil
+}
+
+func isAncestor(dir, branch, base string) bool {
+	_, err := runGit(dir, "merge-base", "--is-ancestor", branch, base)
+	return err == nil
+}
+
+// completedTicket returns the highest sequenced ticket of a session in
+// tickets/completed/ at ref
+func completedTicket(dir, ref, session string) string {
+	prefix := filepath.ToSlash(filepath.Join("tickets", Completed, session)) + "/"
+	out, err := runGit(dir, "ls-tree", "--name-only", ref, prefix)
+	if err != nil {
+		return ""
+	}
+	var paths []string
+	for _, path := range strings.Split(out, "\n") {
+		if IsTicketFile(filepath.Base(path)) {
+			paths = append(paths, path)
+		}
+	}
+	return latest(paths, Completed)
+}
+
+func fileAt(dir, ref, path string) bool {
+	_, err := runGit(dir, "cat-file", "-e", ref+":"+path)
+	return err == nil
+}
+
+// worktreeFor returns the linked worktree that has branch checked out
+func worktreeFor(dir, branch string) string {
+	out, err := runGit(dir, "worktree", "list", "--porcelain")
+	if err != nil {
+		return ""
+	}
+	current := ""
+	for _, line := range strings.Split(out, "\n") {
+		if path, ok := strings.CutPrefix(line, "worktree "); ok {
+			current = path
+		} else if line == "branch refs/heads/"+branch {
+			return current
+		}
+	}
+	return ""
+}
+
+func relPath(root, path string) string {
+	if rel, err := filepath.Rel(resolve(root), resolve(path)); err == nil {
+		return rel
+	}
+	return path
+}
+
+func sameDir(a, b string) bool {
+	return resolve(a) == resolve(b)
+}
+
+// insideDir reports whether path is strictly below dir
+func insideDir(path, dir string) bool {
+	rel, err := filepath.Rel(resolve(dir), resolve(path))
+	return err == nil && rel != "." && !strings.HasPrefix(rel, "..")
+}
+
+// resolve makes a path absolute with symlinks resolved, as far as it exists
+func resolve(path string) string {
+	path, _ = filepath.Abs(path)
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		return real
+	}
+	if real, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+		return filepath.Join(real, filepath.Base(path))
+	}
+	return path
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
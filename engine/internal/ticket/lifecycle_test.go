@@ -0,0 +1,261 @@
+package ticket
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testRepo creates a repository on main with one queued ticket and returns
+// its root and options that keep worktrees in a temporary directory
+func testRepo(t *testing.T, session string) (string, Options) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	root := filepath.Join(t.TempDir(), "project")
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	git(t, root, "init", "--quiet", "-b", "main")
+	writeTicket(t, root, "queue/TICKET-"+session+".md", session, "null")
+	git(t, root, "add", "-A")
+	git(t, root, "commit", "--quiet", "-m", "queue: "+session)
+
+	opts := Options{
+		Base:         "main",
+		WorktreeBase: t.TempDir(),
+		User:         "tester",
+		Now:          time.Date(2026, 1, 2, 15, 4, 0, 0, time.UTC),
+	}
+	return root, opts
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := runGit(dir, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func execute(t *testing.T, plan *Plan) {
+	t.Helper()
+	if done, err := plan.Execute(nil); err != nil {
+		t.Fatalf("%s stopped after %d of %d steps: %v", plan.Action, done, len(plan.Steps), err)
+	}
+}
+
+// snapshot is everything a lifecycle command can change
+func snapshot(t *testing.T, root string) string {
+	t.Helper()
+	return git(t, root, "status", "--porcelain", "--untracked-files=all") + "\n" +
+		git(t, root, "show-ref") + "\n" +
+		git(t, root, "worktree", "list", "--porcelain")
+}
+
+func assertExists(t *testing.T, path string, want bool) {
+	t.Helper()
+	if _, err := os.Stat(path); (err == nil) != want {
+		t.Errorf("%s exists = %v, want %v", path, err == nil, want)
+	}
+}
+
+func TestLifecycleNoPush(t *testing.T) {
+	root, opts := testRepo(t, "fix-login")
+	queued := filepath.Join(Dir(root), Queue, "TICKET-fix-login.md")
+
+	plan, err := PlanActivate(queued, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.TicketID != "TICKET-fix-login-001" || plan.Branch != "ticket/fix-login" {
+		t.Fatalf("plan = %s on %s, want TICKET-fix-login-001 on ticket/fix-login", plan.TicketID, plan.Branch)
+	}
+	for _, step := range plan.Steps {
+		if strings.Contains(step.Description, "origin") {
+			t.Errorf("--no-push plan has remote step %q", step.Description)
+		}
+	}
+	execute(t, plan)
+
+	worktree := filepath.Join(opts.WorktreeBase, "project", "fix-login")
+	if plan.Worktree != worktree {
+		t.Errorf("worktree = %s, want %s", plan.Worktree, worktree)
+	}
+	activeRel := filepath.Join("tickets", Active, "fix-login", "TICKET-fix-login-001.md")
+	assertExists(t, queued, false)
+	claimed, err := Load(filepath.Join(root, activeRel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claimed.Status != "claimed" || claimed.Sequence != "001" {
+		t.Errorf("claimed ticket status %q sequence %q, want claimed 001", claimed.Status, claimed.Sequence)
+	}
+	if branch := git(t, worktree, "branch", "--show-current"); branch != "ticket/fix-login" {
+		t.Errorf("worktree branch = %s, want ticket/fix-login", branch)
+	}
+	active, err := Load(filepath.Join(worktree, activeRel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active.Status != "in_progress" || active.WorktreePath != worktree {
+		t.Errorf("worktree ticket status %q worktree_path %q, want in_progress %s", active.Status, active.WorktreePath, worktree)
+	}
+	if out := git(t, worktree, "status", "--porcelain"); out != "" {
+		t.Errorf("activate left changes uncommitted:\n%s", out)
+	}
+
+	// Cleanup refuses a branch that is not merged yet
+	if _, err := PlanCleanup(root, plan.Branch, opts); err == nil {
+		t.Error("cleanup of an unmerged branch was planned")
+	}
+
+	plan, err = PlanComplete(worktree, "", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	execute(t, plan)
+	completedRel := filepath.Join("tickets", Completed, "fix-login", "TICKET-fix-login-001.md")
+	assertExists(t, filepath.Join(worktree, activeRel), false)
+	completed, err := Load(filepath.Join(worktree, completedRel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if completed.Status != "approved" {
+		t.Errorf("completed ticket status %q, want approved", completed.Status)
+	}
+	if out := git(t, worktree, "status", "--porcelain"); out != "" {
+		t.Errorf("complete left changes uncommitted:\n%s", out)
+	}
+
+	git(t, root, "merge", "--quiet", "--no-ff", "-m", "merge ticket/fix-login", "ticket/fix-login")
+	plan, err = PlanCleanup(root, "ticket/fix-login", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	execute(t, plan)
+	assertExists(t, worktree, false)
+	if branchExists(root, "ticket/fix-login") {
+		t.Error("ticket/fix-login still exists after cleanup")
+	}
+	if w := worktreeFor(root, "ticket/fix-login"); w != "" {
+		t.Errorf("worktree %s still registered after cleanup", w)
+	}
+}
+
+// A squash merge leaves the branch unmerged for git; the completed ticket on
+// main is what lets cleanup delete it
+func TestCleanupSquashMerged(t *testing.T) {
+	root, opts := testRepo(t, "fix-login")
+	plan, err := PlanActivate(filepath.Join(Dir(root), Queue, "TICKET-fix-login.md"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	execute(t, plan)
+	worktree := plan.Worktree
+	if plan, err = PlanComplete(worktree, "", opts); err != nil {
+		t.Fatal(err)
+	}
+	execute(t, plan)
+
+	git(t, root, "merge", "--quiet", "--squash", "ticket/fix-login")
+	git(t, root, "commit", "--quiet", "-m", "fix login (#1)")
+	if plan, err = PlanCleanup(root, "ticket/fix-login", opts); err != nil {
+		t.Fatal(err)
+	}
+	execute(t, plan)
+	assertExists(t, worktree, false)
+	if branchExists(root, "ticket/fix-login") {
+		t.Error("ticket/fix-login still exists after cleanup")
+	}
+}
+
+func TestLifecycleDryRun(t *testing.T) {
+	root, opts := testRepo(t, "fix-login")
+	queued := filepath.Join(Dir(root), Queue, "TICKET-fix-login.md")
+
+	before := snapshot(t, root)
+	plan, err := PlanActivate(queued, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Steps) == 0 {
+		t.Fatal("activate planned no steps")
+	}
+	if after := snapshot(t, root); after != before {
+		t.Errorf("planning activate changed the repository:\nbefore:\n%s\nafter:\n%s", before, after)
+	}
+	assertExists(t, plan.Worktree, false)
+	execute(t, plan)
+
+	before = snapshot(t, plan.Worktree)
+	if plan, err = PlanComplete(plan.Worktree, "", opts); err != nil {
+		t.Fatal(err)
+	}
+	if after := snapshot(t, plan.Worktree); after != before {
+		t.Errorf("planning complete changed the worktree:\nbefore:\n%s\nafter:\n%s", before, after)
+	}
+	execute(t, plan)
+	git(t, root, "merge", "--quiet", "--no-ff", "-m", "merge", plan.Branch)
+
+	before = snapshot(t, root)
+	if plan, err = PlanCleanup(root, plan.Branch, opts); err != nil {
+		t.Fatal(err)
+	}
+	if after := snapshot(t, root); after != before {
+		t.Errorf("planning cleanup changed the repository:\nbefore:\n%s\nafter:\n%s", before, after)
+	}
+	assertExists(t, plan.Worktree, true)
+}
+
+// With an origin, activate pushes the claim and the branch, and cleanup
+// deletes the remote branch
+func TestLifecyclePush(t *testing.T) {
+	root, opts := testRepo(t, "fix-login")
+	opts.Push = true
+	origin := filepath.Join(t.TempDir(), "origin.git")
+	git(t, root, "init", "--quiet", "--bare", origin)
+	git(t, root, "remote", "add", "origin", origin)
+	git(t, root, "push", "--quiet", "-u", "origin", "main")
+
+	plan, err := PlanActivate(filepath.Join(Dir(root), Queue, "TICKET-fix-login.md"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	execute(t, plan)
+	if git(t, origin, "rev-parse", "main") != git(t, root, "rev-parse", "main") {
+		t.Error("claim commit was not pushed to origin/main")
+	}
+	if _, err := runGit(origin, "rev-parse", "--verify", "refs/heads/ticket/fix-login"); err != nil {
+		t.Error("ticket/fix-login was not pushed to origin")
+	}
+
+	worktree := plan.Worktree
+	if plan, err = PlanComplete(worktree, "", opts); err != nil {
+		t.Fatal(err)
+	}
+	execute(t, plan)
+	git(t, root, "merge", "--quiet", "--no-ff", "-m", "merge", "ticket/fix-login")
+	git(t, root, "push", "--quiet", "origin", "main")
+
+	if plan, err = PlanCleanup(root, "ticket/fix-login", opts); err != nil {
+		t.Fatal(err)
+	}
+	execute(t, plan)
+	if _, err := runGit(origin, "rev-parse", "--verify", "refs/heads/ticket/fix-login"); err == nil {
+		t.Error("ticket/fix-login still exists on origin after cleanup")
+	}
+	assertExists(t, worktree, false)
+}
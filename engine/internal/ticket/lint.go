@@ -0,0 +1,182 @@
+package ticket
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dandoyle-pdm/workflow-guard/engine/internal/gitstate"
+)
+
+// Lint checks, reported in Finding.Check
+const (
+	CheckSchema            = "schema"             // Validate problems
+	CheckDirectory         = "directory"          // Directory differs from session_id
+	CheckDuplicateSequence = "duplicate-sequence" // Two tickets of a session share a sequence
+	CheckNoBranch          = "no-branch"          // Active ticket without its ticket/<session-id> branch
+	CheckNoWorktree        = "no-worktree"        // Active ticket without a worktree on its branch
+	CheckUncheckedCriteria = "unchecked-criteria" // Completed ticket with an open acceptance box
+	CheckPlaceholder       = "placeholder"        // Template placeholder left in the frontmatter
+)
+
+// Finding is one problem lint found in a ticket
+type Finding struct {
+	Path    string `json:"path"` // Relative to the linted root
+	Ticket  string `json:"ticket,omitempty"`
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+// LintReport is the result of linting a tickets tree
+type LintReport struct {
+	Root     string    `json:"root"`
+	Tickets  int       `json:"tickets"`
+	Findings []Finding `json:"findings"`
+}
+
+// placeholder matches template values such as {assigned at activation}
+var placeholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+// uncheckedBox matches an open markdown checkbox item
+var uncheckedBox = regexp.MustCompile(`^\s*[-*]\s+\[ \]\s*(.*)$`)
+
+// Lint audits every ticket under root/tickets. Besides each ticket's own
+// Validate problems it checks what only shows across the tree or against
+// git: directories named after another session, sequences used twice in
+// a session, active tickets whose branch or worktree is missing, completed
+// tickets with unchecked acceptance criteria and leftover placeholders.
+// Findings are ordered by path.
+func Lint(root string) (*LintReport, error) {
+	tickets, err := Discover(root)
+	report := &LintReport{Root: root, Tickets: len(tickets), Findings: []Finding{}}
+	add := func(t *Ticket, check, format string, args ...any) {
+		report.Findings = append(report.Findings, Finding{
+			Path:    relPath(root, t.Path),
+			Ticket:  t.ID,
+			Check:   check,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	_, gitOK := gitstate.Read(root)
+	sequences := map[string]map[int]*Ticket{}
+	for _, t := range tickets {
+		for _, problem := range t.Validate() {
+			add(t, CheckSchema, "%s", problem)
+		}
+
+		nameSession, sequence, named := NameParts(filepath.Base(t.Path), t.Location)
+		session := t.SessionID
+		if session == "" {
+			session = nameSession
+		}
+		if t.Dir != "" && session != "" && t.Dir != session {
+			add(t, CheckDirectory, "directory %s does not match session_id %s (expected tickets/%s/%s/)",
+				t.Dir, session, t.Location, session)
+		}
+
+		// Sequences are unique per session across active and completed
+		if n, ok := SequenceNumber(sequence); named && ok && t.Location != Queue {
+			if sequences[nameSession] == nil {
+				sequences[nameSession] = map[int]*Ticket{}
+			}
+			if first := sequences[nameSession][n]; first != nil {
+				add(t, CheckDuplicateSequence, "sequence %03d of session %s is also used by %s",
+					n, nameSession, relPath(root, first.Path))
+			} else {
+				sequences[nameSession][n] = t
+			}
+		}
+
+		// The branch is named after the directory, as ForBranch looks it up
+		if t.Location == Active && t.Dir != "" && gitOK {
+			branch := "ticket/" + t.Dir
+			if !branchExists(root, branch) && !remoteBranchExists(root, branch) {
+				add(t, CheckNoBranch, "active ticket has no branch %s", branch)
+			} else if worktreeFor(root, branch) == "" {
+				add(t, CheckNoWorktree, "active ticket has no worktree checked out on %s (worktree_path: %s)",
+					branch, valueOr(t.WorktreePath, "null"))
+			}
+		}
+
+		if t.Location == Completed {
+			if open := uncheckedCriteria(t.Body); len(open) > 0 {
+				add(t, CheckUncheckedCriteria, "%d unchecked acceptance %s, first: %q",
+					len(open), plural(len(open), "criterion", "criteria"), open[0])
+			}
+		}
+
+		keys := make([]string, 0, len(t.Meta))
+		for key := range t.Meta {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if placeholder.MatchString(t.Meta[key]) {
+				add(t, CheckPlaceholder, "%s still holds the template placeholder %s", key, t.Meta[key])
+			}
+		}
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return report.Findings[i].Path < report.Findings[j].Path
+	})
+	return report, err
+}
+
+// uncheckedCriteria returns the open checkbox items under the
+// "Acceptance Criteria" heading, up to the next heading of the same or a
+// higher level. Fenced code blocks are literal: their # lines are not
+// headings and their boxes are not criteria.
+func uncheckedCriteria(body string) []string {
+	var open []string
+	level := 0
+	fenced := false
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			depth := len(line) - len(strings.TrimLeft(line, "#"))
+			title := strings.TrimSpace(line[depth:])
+			switch {
+			case strings.EqualFold(title, "Acceptance Criteria"):
+				level = depth
+			case level > 0 && depth <= level:
+				level = 0
+			}
+			continue
+		}
+		if level > 0 {
+			if m := uncheckedBox.FindStringSubmatch(line); m != nil {
+				open = append(open, strings.TrimSpace(m[1]))
+			}
+		}
+	}
+	return open
+}
+
+func remoteBranchExists(dir, branch string) bool {
+	_, err := runGit(dir, "show-ref", "--verify", "--quiet", "refs/remotes/origin/"+branch)
+	return err == nil
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
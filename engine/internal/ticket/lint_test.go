@@ -0,0 +1,249 @@
+package ticket
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// editTicket rewrites a ticket written by writeTicket
+func editTicket(t *testing.T, path string, edit func(string) string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(edit(string(data))), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// lintChecks returns the checks found per path, in report order
+func lintChecks(t *testing.T, root string) map[string][]string {
+	t.Helper()
+	report, err := Lint(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks := map[string][]string{}
+	for _, f := range report.Findings {
+		checks[f.Path] = append(checks[f.Path], f.Check)
+	}
+	return checks
+}
+
+func TestLintDirectory(t *testing.T) {
+	root := t.TempDir()
+	writeTicket(t, root, "completed/bar/TICKET-foo-001.md", "foo", "001")
+	writeTicket(t, root, "completed/foo/TICKET-foo-002.md", "foo", "002")
+
+	report, err := Lint(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Finding{{
+		Path:    "tickets/completed/bar/TICKET-foo-001.md",
+		Ticket:  "TICKET-foo-001",
+		Check:   CheckDirectory,
+		Message: "directory bar does not match session_id foo (expected tickets/completed/foo/)",
+	}}
+	if !reflect.DeepEqual(report.Findings, want) {
+		t.Errorf("findings = %+v, want %+v", report.Findings, want)
+	}
+}
+
+func TestLintDuplicateSequence(t *testing.T) {
+	root := t.TempDir()
+	writeTicket(t, root, "active/foo/TICKET-foo-003.md", "foo", "003")
+	writeTicket(t, root, "completed/foo/TICKET-foo-002.md", "foo", "002")
+	// Queued tickets have no sequence yet
+	writeTicket(t, root, "queue/TICKET-foo.md", "foo", "null")
+	// Active tickets are discovered first, so the completed one is reported
+	writeTicket(t, root, "active/foo/TICKET-foo-001.md", "foo", "001")
+	dup := writeTicket(t, root, "completed/foo/TICKET-foo-001.md", "foo", "001")
+
+	checks := lintChecks(t, root)
+	if want := map[string][]string{relPath(root, dup): {CheckDuplicateSequence}}; !reflect.DeepEqual(checks, want) {
+		t.Errorf("checks = %v, want %v", checks, want)
+	}
+}
+
+func TestLintActiveBranch(t *testing.T) {
+	root, opts := testRepo(t, "queued")
+	noBranch := writeTicket(t, root, "active/alpha/TICKET-alpha-001.md", "alpha", "001")
+	noWorktree := writeTicket(t, root, "active/beta/TICKET-beta-001.md", "beta", "001")
+	writeTicket(t, root, "active/gamma/TICKET-gamma-001.md", "gamma", "001")
+	// Completed tickets keep no branch
+	writeTicket(t, root, "completed/delta/TICKET-delta-001.md", "delta", "001")
+
+	git(t, root, "branch", "ticket/beta")
+	git(t, root, "worktree", "add", "--quiet", "-b", "ticket/gamma", filepath.Join(opts.WorktreeBase, "gamma"))
+
+	checks := lintChecks(t, root)
+	want := map[string][]string{
+		relPath(root, noBranch):   {CheckNoBranch},
+		relPath(root, noWorktree): {CheckNoWorktree},
+	}
+	if !reflect.DeepEqual(checks, want) {
+		t.Errorf("checks = %v, want %v", checks, want)
+	}
+}
+
+func TestLintActiveOutsideGit(t *testing.T) {
+	root := t.TempDir()
+	writeTicket(t, root, "active/alpha/TICKET-alpha-001.md", "alpha", "001")
+
+	if checks := lintChecks(t, root); len(checks) != 0 {
+		t.Errorf("checks = %v, want none without a repository", checks)
+	}
+}
+
+func TestLintUncheckedCriteria(t *testing.T) {
+	tests := []struct {
+		name     string
+		location string
+		body     string
+		want     string // Message, empty for no finding
+	}{
+		{
+			name:     "open boxes",
+			location: Completed,
+			body: "## Acceptance Criteria\n" +
+				"- [x] done\n" +
+				"- [ ] first open\n" +
+				"* [ ] second open\n",
+			want: `2 unchecked acceptance criteria, first: "first open"`,
+		},
+		{
+			name:     "all checked",
+			location: Completed,
+			body:     "## Acceptance Criteria\n- [x] done\n- [X] also done\n",
+		},
+		{
+			name:     "boxes in a code fence are examples",
+			location: Completed,
+			body: "## Acceptance Criteria\n" +
+				"- [x] done\n" +
+				"```markdown\n" +
+				"# Not a heading\n" +
+				"- [ ] example box\n" +
+				"```\n",
+		},
+		{
+			name:     "criteria continue after a fenced heading",
+			location: Completed,
+			body: "## Acceptance Criteria\n" +
+				"```sh\n" +
+				"# comment, not a heading\n" +
+				"```\n" +
+				"- [ ] after the fence\n",
+			want: `1 unchecked acceptance criterion, first: "after the fence"`,
+		},
+		{
+			name:     "boxes under other headings",
+			location: Completed,
+			body: "## Acceptance Criteria\n" +
+				"- [x] done\n" +
+				"### Details\n" +
+				"- [ ] nested under criteria\n" +
+				"## Follow-up\n" +
+				"- [ ] not a criterion\n",
+			want: `1 unchecked acceptance criterion, first: "nested under criteria"`,
+		},
+		{
+			name:     "active tickets are still in progress",
+			location: Active,
+			body:     "## Acceptance Criteria\n- [ ] open\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			path := writeTicket(t, root, tt.location+"/foo/TICKET-foo-001.md", "foo", "001")
+			editTicket(t, path, func(s string) string { return s + "\n" + tt.body })
+
+			report, err := Lint(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			for _, f := range report.Findings {
+				if f.Check == CheckUncheckedCriteria {
+					got = f.Message
+				}
+			}
+			if got != tt.want {
+				t.Errorf("message = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintPlaceholder(t *testing.T) {
+	root := t.TempDir()
+	path := writeTicket(t, root, "completed/foo/TICKET-foo-001.md", "foo", "001")
+	editTicket(t, path, func(s string) string {
+		return strings.Replace(s, "worktree_path: null", "worktree_path: {assigned at activation}", 1)
+	})
+
+	report, err := Lint(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Finding{{
+		Path:    "tickets/completed/foo/TICKET-foo-001.md",
+		Ticket:  "TICKET-foo-001",
+		Check:   CheckPlaceholder,
+		Message: "worktree_path still holds the template placeholder {assigned at activation}",
+	}}
+	if !reflect.DeepEqual(report.Findings, want) {
+		t.Errorf("findings = %+v, want %+v", report.Findings, want)
+	}
+}
+
+func TestLintReportJSON(t *testing.T) {
+	root := t.TempDir()
+	clean, err := Lint(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := json.Marshal(clean)
+	// No findings is an empty list, not null
+	if want := `{"root":` + quote(root) + `,"tickets":0,"findings":[]}`; string(out) != want {
+		t.Errorf("json = %s, want %s", out, want)
+	}
+
+	path := writeTicket(t, root, "completed/bar/TICKET-foo-001.md", "foo", "001")
+	editTicket(t, path, func(s string) string { return strings.Replace(s, "ticket_id: TICKET-foo-001\n", "", 1) })
+	report, err := Lint(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ = json.Marshal(report)
+	var decoded struct {
+		Root     string           `json:"root"`
+		Tickets  int              `json:"tickets"`
+		Findings []map[string]any `json:"findings"`
+	}
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Root != root || decoded.Tickets != 1 || len(decoded.Findings) != 2 {
+		t.Fatalf("json = %s", out)
+	}
+	for _, f := range decoded.Findings {
+		// Without a ticket_id the ticket key is left out
+		if len(f) != 3 || f["path"] != "tickets/completed/bar/TICKET-foo-001.md" || f["check"] == nil || f["message"] == nil {
+			t.Errorf("finding = %v, want path, check and message", f)
+		}
+	}
+}
+
+func quote(s string) string {
+	out, _ := json.Marshal(s)
+	return string(out)
+}
@@ -0,0 +1,276 @@
+package ticket
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Ticket locations under tickets/
+const (
+	Queue     = "queue"
+	Active    = "active"
+	Completed = "completed"
+)
+
+// Locations in lifecycle order
+var Locations = []string{Queue, Active, Completed}
+
+// Statuses are the allowed values of status (see tickets/TEMPLATE.md)
+var Statuses = []string{
+	"open", "claimed", "in_progress", "critic_review", "expediter_review", "approved", "blocked",
+}
+
+// CycleTypes are the allowed values of cycle_type
+var CycleTypes = []string{"development", "documentation", "architecture", "product", "design"}
+
+// RequiredKeys must appear in every ticket's frontmatter, even when null
+var RequiredKeys = []string{"ticket_id", "session_id", "sequence", "status", "cycle_type", "worktree_path"}
+
+// sequencedName matches TICKET-<session-id>-NNN.md (active and completed)
+// and queueName TICKET-<session-id>.md, whose session id may end in digits
+// of its own (TICKET-issue-456.md)
+var (
+	sequencedName = regexp.MustCompile(`^TICKET-([a-z0-9]+(?:-[a-z0-9]+)*)-([0-9]{3})\.md$`)
+	queueName     = regexp.MustCompile(`^TICKET-([a-z0-9]+(?:-[a-z0-9]+)*)\.md$`)
+)
+
+// Ticket is a ticket file and its frontmatter
+type Ticket struct {
+	Path     string // File the ticket was read from
+	Location string // queue, active or completed; "" outside tickets/
+	Dir      string // Directory under the location, "" for flat queue files
+
+	ID           string // ticket_id
+	SessionID    string // session_id
+	Sequence     string // sequence as written, e.g. "001"
+	Status       string
+	CycleType    string
+	WorktreePath string
+	Title        string
+	Parent       string // parent_ticket
+
+	// Meta holds every frontmatter key as written; null values are empty
+	Meta map[string]string
+	// HasFrontmatter is false when the file has no --- block
+	HasFrontmatter bool
+	Body           string
+}
+
+// Load reads and parses a ticket file
+func Load(path string) (*Ticket, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, data), nil
+}
+
+// Parse reads a ticket's frontmatter. Values are taken line by line as
+// the lifecycle scripts do, so template placeholders such as
+// "{assigned at activation}" stay strings instead of breaking YAML.
+func Parse(path string, data []byte) *Ticket {
+	t := &Ticket{Path: path, Meta: map[string]string{}}
+	t.Location, t.Dir = locate(path)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var body strings.Builder
+	inComment, inFront, done := false, false, false
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case done:
+			body.WriteString(line + "\n")
+		case inFront:
+			if trimmed == "---" {
+				inFront, done = false, true
+				continue
+			}
+			key, value, ok := strings.Cut(line, ":")
+			if !ok || strings.HasPrefix(trimmed, "#") || key != strings.TrimSpace(key) {
+				continue
+			}
+			t.Meta[key] = cleanValue(value)
+		case inComment:
+			// The template opens with an HTML comment before the frontmatter
+			inComment = !strings.Contains(line, "-->")
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "<!--"):
+			inComment = !strings.Contains(trimmed, "-->")
+		case trimmed == "---":
+			inFront, t.HasFrontmatter = true, true
+		default:
+			done = true
+			body.WriteString(line + "\n")
+		}
+	}
+	t.Body = body.String()
+
+	t.ID = t.Meta["ticket_id"]
+	t.SessionID = t.Meta["session_id"]
+	t.Sequence = t.Meta["sequence"]
+	t.Status = t.Meta["status"]
+	t.CycleType = t.Meta["cycle_type"]
+	t.WorktreePath = t.Meta["worktree_path"]
+	t.Title = t.Meta["title"]
+	t.Parent = t.Meta["parent_ticket"]
+	return t
+}
+
+func cleanValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	if value == "null" || value == "~" {
+		return ""
+	}
+	return value
+}
+
+// locate finds the location and session directory of a path inside
+// tickets/<location>/[<dir>/]TICKET-*.md
+func locate(path string) (string, string) {
+	parts := strings.Split(filepath.ToSlash(filepath.Clean(path)), "/")
+	for i := len(parts) - 2; i >= 1; i-- {
+		if parts[i-1] != "tickets" || !isLocation(parts[i]) {
+			continue
+		}
+		switch len(parts) - i {
+		case 2:
+			return parts[i], ""
+		case 3:
+			return parts[i], parts[i+1]
+		}
+	}
+	return "", ""
+}
+
+func isLocation(name string) bool {
+	for _, location := range Locations {
+		if name == location {
+			return true
+		}
+	}
+	return false
+}
+
+// NameParts splits a ticket filename into its session id and sequence.
+// The location decides the form: queue names have no sequence, so all of
+// TICKET-issue-456.md is the session id, while active and completed names
+// end in one. Outside tickets/ ("") a trailing -NNN is taken as the
+// sequence. ok is false for names that aren't ticket files there.
+func NameParts(name, location string) (session, sequence string, ok bool) {
+	if location != Queue {
+		if m := sequencedName.FindStringSubmatch(name); m != nil {
+			return m[1], m[2], true
+		}
+		if location != "" {
+			return "", "", false
+		}
+	}
+	if m := queueName.FindStringSubmatch(name); m != nil {
+		return m[1], "", true
+	}
+	return "", "", false
+}
+
+// Name returns the filename of the ticket for a session and sequence
+func Name(session string, sequence int) string {
+	if sequence <= 0 {
+		return "TICKET-" + session + ".md"
+	}
+	return fmt.Sprintf("TICKET-%s-%03d.md", session, sequence)
+}
+
+// SequenceNumber parses a sequence such as "001"; ok is false for null
+// values and placeholders
+func SequenceNumber(sequence string) (int, bool) {
+	n, err := strconv.Atoi(sequence)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// Validate returns the problems in a single ticket: missing frontmatter
+// keys, unknown status or cycle_type, and a filename whose session id or
+// sequence disagrees with the frontmatter. An empty list means valid.
+func (t *Ticket) Validate() []string {
+	var problems []string
+	if !t.HasFrontmatter {
+		return append(problems, "no frontmatter (expected a --- block with "+strings.Join(RequiredKeys, ", ")+")")
+	}
+	for _, key := range RequiredKeys {
+		if _, ok := t.Meta[key]; !ok {
+			problems = append(problems, "missing frontmatter key "+key)
+		}
+	}
+	if _, ok := t.Meta["status"]; ok && !contains(Statuses, t.Status) {
+		problems = append(problems, fmt.Sprintf("status %q is not one of %s", t.Status, strings.Join(Statuses, ", ")))
+	}
+	if _, ok := t.Meta["cycle_type"]; ok && !contains(CycleTypes, t.CycleType) {
+		problems = append(problems, fmt.Sprintf("cycle_type %q is not one of %s", t.CycleType, strings.Join(CycleTypes, ", ")))
+	}
+
+	name := filepath.Base(t.Path)
+	session, sequence, ok := NameParts(name, t.Location)
+	if !ok {
+		queued, _, isQueueName := NameParts(name, Queue)
+		if !isQueueName {
+			problems = append(problems, fmt.Sprintf("filename %s does not match TICKET-<session-id>[-NNN].md", name))
+			return problems
+		}
+		problems = append(problems, fmt.Sprintf("filename %s has no sequence (expected TICKET-%s-NNN.md in %s/)", name, queued, t.Location))
+		session = queued
+	}
+	if t.SessionID != "" && t.SessionID != session {
+		problems = append(problems, fmt.Sprintf("session_id %s does not match filename %s", t.SessionID, name))
+	}
+	if t.ID != "" && t.ID != strings.TrimSuffix(name, ".md") {
+		problems = append(problems, fmt.Sprintf("ticket_id %s does not match filename %s", t.ID, name))
+	}
+	if sequence != "" {
+		want, _ := SequenceNumber(sequence)
+		if got, ok := SequenceNumber(t.Sequence); !ok || got != want {
+			problems = append(problems, fmt.Sprintf("sequence %q does not match filename %s (expected %s)", t.Sequence, name, sequence))
+		}
+	}
+	return problems
+}
+
+// Fields returns the ticket for condition field access (ticket.*)
+func (t *Ticket) Fields() map[string]any {
+	problems := t.Validate()
+	return map[string]any{
+		"found":         true,
+		"id":            t.ID,
+		"session_id":    t.SessionID,
+		"sequence":      t.Sequence,
+		"status":        t.Status,
+		"cycle_type":    t.CycleType,
+		"worktree_path": t.WorktreePath,
+		"title":         t.Title,
+		"parent_ticket": t.Parent,
+		"location":      t.Location,
+		"path":          t.Path,
+		"valid":         len(problems) == 0,
+		"problems":      problems,
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package ticket
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNameParts(t *testing.T) {
+	tests := []struct {
+		name, location    string
+		session, sequence string
+		ok                bool
+	}{
+		{"TICKET-issue-456.md", Queue, "issue-456", "", true},
+		{"TICKET-fix-login.md", Queue, "fix-login", "", true},
+		{"TICKET-issue-456-001.md", Active, "issue-456", "001", true},
+		{"TICKET-issue-456-002.md", Completed, "issue-456", "002", true},
+		{"TICKET-issue-456.md", Active, "issue", "456", true},
+		{"TICKET-issue.md", Active, "", "", false},
+		{"TICKET-fix-login.md", Completed, "", "", false},
+		{"TICKET-fix-login-001.md", "", "fix-login", "001", true},
+		{"TICKET-fix-login.md", "", "fix-login", "", true},
+		{"TICKET-Fix.md", Queue, "", "", false},
+		{"notes.md", Active, "", "", false},
+	}
+	for _, tt := range tests {
+		session, sequence, ok := NameParts(tt.name, tt.location)
+		if session != tt.session || sequence != tt.sequence || ok != tt.ok {
+			t.Errorf("NameParts(%q, %q) = %q, %q, %v, want %q, %q, %v",
+				tt.name, tt.location, session, sequence, ok, tt.session, tt.sequence, tt.ok)
+		}
+	}
+}
+
+func TestValidateQueueSessionEndingInDigits(t *testing.T) {
+	root := t.TempDir()
+	path := writeTicket(t, root, "queue/TICKET-issue-456.md", "issue-456", "null")
+
+	tk, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems := tk.Validate(); len(problems) != 0 {
+		t.Errorf("problems = %v, want none", problems)
+	}
+
+	found, err := ForSession(root, "issue-456")
+	if err != nil || found == nil || found.Path != path {
+		t.Errorf("ForSession(issue-456) = %v, %v, want %s", found, err, path)
+	}
+	if found, _ := ForSession(root, "issue"); found != nil {
+		t.Errorf("ForSession(issue) = %s, want none", found.Path)
+	}
+}
+
+func TestValidateActiveWithoutSequence(t *testing.T) {
+	root := t.TempDir()
+	path := writeTicket(t, root, "active/foo/TICKET-foo.md", "foo", "null")
+
+	tk, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "filename TICKET-foo.md has no sequence (expected TICKET-foo-NNN.md in active/)"
+	if problems := tk.Validate(); len(problems) != 1 || problems[0] != want {
+		t.Errorf("problems = %q, want [%q]", problems, want)
+	}
+}
+
+func TestLintSequencesBySession(t *testing.T) {
+	root := t.TempDir()
+	// Sessions "issue" and "issue-456" must not be confused
+	writeTicket(t, root, "queue/TICKET-issue-456.md", "issue-456", "null")
+	writeTicket(t, root, "completed/issue/TICKET-issue-001.md", "issue", "001")
+	writeTicket(t, root, "completed/issue-456/TICKET-issue-456-001.md", "issue-456", "001")
+
+	report, err := Lint(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range report.Findings {
+		if f.Check == CheckDuplicateSequence || f.Check == CheckSchema || f.Check == CheckDirectory {
+			t.Errorf("unexpected finding %s: %s (%s)", f.Check, f.Message, f.Path)
+		}
+	}
+}
+
+// writeTicket writes a valid ticket under root/tickets/
+func writeTicket(t *testing.T, root, rel, session, sequence string) string {
+	t.Helper()
+	path := filepath.Join(Dir(root), rel)
+	id := filepath.Base(rel[:len(rel)-len(".md")])
+	status := map[string]string{Queue: "open", Active: "in_progress", Completed: "approved"}[strings.Split(rel, "/")[0]]
+	content := "---\n" +
+		"ticket_id: " + id + "\n" +
+		"session_id: " + session + "\n" +
+		"sequence: " + sequence + "\n" +
+		"status: " + status + "\n" +
+		"cycle_type: development\n" +
+		"worktree_path: null\n" +
+		"---\n\n# " + id + "\n"
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
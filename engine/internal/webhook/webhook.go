@@ -0,0 +1,179 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Delivery is one POST to a webhook. Failed deliveries are spooled as JSON
+// and retried by `hookctl spool flush`.
+type Delivery struct {
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      string            `json:"body"`
+	Created   time.Time         `json:"created"`
+	Attempts  int               `json:"attempts"`
+	LastError string            `json:"last_error,omitempty"`
+}
+
+// Send POSTs the delivery, retrying failed attempts while ctx allows. The
+// context deadline bounds the total time spent, including retries.
+func Send(ctx context.Context, client *http.Client, d *Delivery, retries int) error {
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(attempt) * 50 * time.Millisecond
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(backoff):
+			}
+		}
+		d.Attempts++
+		if err = post(ctx, client, d); err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func post(ctx context.Context, client *http.Client, d *Delivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, strings.NewReader(d.Body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "workflow-guard")
+	for name, value := range d.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s: %s", d.URL, resp.Status)
+	}
+	return nil
+}
+
+// Spool is a directory of deliveries waiting to be retried
+type Spool struct {
+	Dir string
+}
+
+// DefaultSpoolDir returns the spool location.
+// WORKFLOW_GUARD_SPOOL_DIR overrides ~/.claude/workflow-guard/spool.
+func DefaultSpoolDir() string {
+	if dir := os.Getenv("WORKFLOW_GUARD_SPOOL_DIR"); dir != "" {
+		return dir
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "workflow-guard", "spool")
+	}
+	return filepath.Join(homeDir, ".claude", "workflow-guard", "spool")
+}
+
+// Save writes a delivery to the spool. Files are readable only by their
+// owner, since headers may carry credentials.
+func (s Spool) Save(d *Delivery) error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	name := fmt.Sprintf("%d-%s.json", d.Created.UnixNano(), hex.EncodeToString(suffix))
+
+	tmp := filepath.Join(s.Dir, "."+name)
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.Dir, name))
+}
+
+// Pending lists spooled delivery files, oldest first
+func (s Spool) Pending() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.Dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// FlushResult reports the outcome of retrying one spooled delivery
+type FlushResult struct {
+	File     string
+	Delivery *Delivery
+	Err      error
+}
+
+// Flush retries every spooled delivery in order. Delivered files are
+// removed; failed ones are kept with their attempt count and error updated.
+func (s Spool) Flush(client *http.Client, timeout time.Duration) ([]FlushResult, error) {
+	files, err := s.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []FlushResult
+	for _, file := range files {
+		result := FlushResult{File: file}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+		var d Delivery
+		if err := json.Unmarshal(data, &d); err != nil {
+			result.Err = fmt.Errorf("corrupt spool file: %w", err)
+			results = append(results, result)
+			continue
+		}
+		result.Delivery = &d
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		result.Err = Send(ctx, client, &d, 0)
+		cancel()
+
+		if result.Err == nil {
+			os.Remove(file)
+		} else {
+			d.LastError = result.Err.Error()
+			if data, err := json.MarshalIndent(&d, "", "  "); err == nil {
+				os.WriteFile(file, data, 0600)
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// ValidBody reports whether a rendered body is JSON
+func ValidBody(body string) bool {
+	return json.Valid(bytes.TrimSpace([]byte(body)))
+}
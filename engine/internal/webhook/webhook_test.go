@@ -0,0 +1,152 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// failingServer answers 500 to the first failures requests, then 204
+func failingServer(t *testing.T, failures int32) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= failures {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestSendDelivers(t *testing.T) {
+	var got *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got, body = r, string(data)
+	}))
+	defer server.Close()
+
+	d := &Delivery{
+		URL:     server.URL,
+		Headers: map[string]string{"Authorization": "Bearer token"},
+		Body:    `{"event":"PreToolUse"}`,
+	}
+	if err := Send(context.Background(), server.Client(), d, 0); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if d.Attempts != 1 {
+		t.Errorf("attempts = %d, want 1", d.Attempts)
+	}
+	if got.Method != http.MethodPost {
+		t.Errorf("method = %s, want POST", got.Method)
+	}
+	for name, want := range map[string]string{
+		"Content-Type":  "application/json",
+		"User-Agent":    "workflow-guard",
+		"Authorization": "Bearer token",
+	} {
+		if value := got.Header.Get(name); value != want {
+			t.Errorf("%s = %q, want %q", name, value, want)
+		}
+	}
+	if body != d.Body {
+		t.Errorf("body = %q, want %q", body, d.Body)
+	}
+}
+
+func TestSendRetries(t *testing.T) {
+	server, calls := failingServer(t, 2)
+
+	d := &Delivery{URL: server.URL, Body: "{}"}
+	if err := Send(context.Background(), server.Client(), d, 2); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if d.Attempts != 3 || *calls != 3 {
+		t.Errorf("attempts = %d, calls = %d, want 3", d.Attempts, *calls)
+	}
+}
+
+func TestSendGivesUp(t *testing.T) {
+	server, calls := failingServer(t, 100)
+
+	d := &Delivery{URL: server.URL, Body: "{}"}
+	if err := Send(context.Background(), server.Client(), d, 1); err == nil {
+		t.Fatal("Send succeeded against a failing server")
+	}
+	if d.Attempts != 2 || *calls != 2 {
+		t.Errorf("attempts = %d, calls = %d, want 2", d.Attempts, *calls)
+	}
+}
+
+func TestSendStopsAtDeadline(t *testing.T) {
+	server, _ := failingServer(t, 100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	d := &Delivery{URL: server.URL, Body: "{}"}
+	if err := Send(ctx, server.Client(), d, 100); err == nil {
+		t.Fatal("Send succeeded against a failing server")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Send took %v past a 120ms deadline", elapsed)
+	}
+}
+
+func TestSpoolFlush(t *testing.T) {
+	delivered, _ := failingServer(t, 0)
+	failing, _ := failingServer(t, 100)
+	spool := Spool{Dir: filepath.Join(t.TempDir(), "spool")}
+
+	created := time.Now().UTC()
+	for i, url := range []string{delivered.URL, failing.URL} {
+		d := &Delivery{URL: url, Body: "{}", Created: created.Add(time.Duration(i)), Attempts: 1}
+		if err := spool.Save(d); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	files, err := spool.Pending()
+	if err != nil || len(files) != 2 {
+		t.Fatalf("Pending = %v, %v, want 2 files", files, err)
+	}
+	if info, err := os.Stat(files[0]); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Errorf("spool file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	results, err := spool.Flush(http.DefaultClient, time.Second)
+	if err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if len(results) != 2 || results[0].Err != nil || results[1].Err == nil {
+		t.Fatalf("results = %+v, want the first delivered and the second failed", results)
+	}
+
+	files, _ = spool.Pending()
+	if len(files) != 1 {
+		t.Fatalf("pending after flush = %v, want the failed delivery", files)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kept Delivery
+	if err := json.Unmarshal(data, &kept); err != nil {
+		t.Fatal(err)
+	}
+	if kept.URL != failing.URL || kept.Attempts != 2 || kept.LastError == "" {
+		t.Errorf("kept delivery = %+v, want %s with 2 attempts and an error", kept, failing.URL)
+	}
+}
@@ -5,7 +5,7 @@
 # branch is a protected branch (main, master, production). This ensures all
 # changes go through the worktree + PR workflow.
 #
-# Security hardened following patterns from enforce-pr-workflow.sh:
+# Security hardened:
 # - Command injection prevention via printf instead of echo
 # - Proper jq error handling
 # - Input validation
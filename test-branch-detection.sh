@@ -1,10 +1,29 @@
 #!/usr/bin/env bash
-# Test script to verify branch detection fix in block-unreviewed-edits.sh
+# Test script to verify branch detection for edits (engine rules
+# require-agent-for-edits and require-worktree-for-writes)
 
 set -euo pipefail
 
-SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
-HOOK="${SCRIPT_DIR}/hooks/block-unreviewed-edits.sh"
+# Events run through the committed engine dispatcher, the binary the
+# plugin's hooks run, with this repository's rule files; a deny becomes
+# exit 2, as the shell hooks it replaced returned
+readonly SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+ENGINE_HOME="$(mktemp -d)"
+trap 'rm -rf "$ENGINE_HOME"' EXIT
+mkdir -p "$ENGINE_HOME/.claude"
+cp "$SCRIPT_DIR"/engine/{settings,conditions,actions,rules}.yaml "$ENGINE_HOME/.claude/"
+readonly DISPATCHER="$SCRIPT_DIR/engine/bin/dispatcher"
+make -s -C "$SCRIPT_DIR/engine" check-bin
+
+run_engine() {
+    local output
+    output=$(HOME="$ENGINE_HOME" CLAUDE_PROJECT_DIR="" CLAUDE_HOOK_TYPE=PreToolUse \
+        WORKFLOW_GUARD_NO_DAEMON=1 "$DISPATCHER")
+    if [[ "$output" == *'"permissionDecision":"deny"'* ]]; then
+        printf '%s\n' "$output" >&2
+        return 2
+    fi
+}
 
 echo "Testing branch detection security fix..."
 echo
@@ -23,7 +42,7 @@ test_json=$(cat <<'EOF'
 EOF
 )
 
-if echo "$test_json" | bash "$HOOK" 2>&1; then
+if echo "$test_json" | run_engine 2>&1; then
     echo "  FAILED: Should have blocked operation"
     exit 1
 else
@@ -44,7 +63,8 @@ test_json=$(cat <<EOF
 {
   "tool_name": "Write",
   "tool_input": {
-    "file_path": "${SCRIPT_DIR}/tickets/queue/TICKET-test.md"
+    "file_path": "${SCRIPT_DIR}/tickets/queue/TICKET-test.md",
+    "content": "---\\nticket_id: null\\nsession_id: test\\nsequence: null\\nstatus: open\\ncycle_type: development\\nworktree_path: null\\n---\\n"
   },
   "transcript_path": "/dev/null",
   "cwd": "${SCRIPT_DIR}"
@@ -52,7 +72,7 @@ test_json=$(cat <<EOF
 EOF
 )
 
-if echo "$test_json" | bash "$HOOK" 2>&1; then
+if echo "$test_json" | run_engine 2>&1; then
     echo "  PASSED: Allowed workflow metadata in valid git repo"
 else
     echo "  FAILED: Should have allowed workflow metadata"
@@ -67,7 +87,8 @@ test_json=$(cat <<EOF
 {
   "tool_name": "Write",
   "tool_input": {
-    "file_path": "${SCRIPT_DIR}/tickets/queue/TICKET-test.md"
+    "file_path": "${SCRIPT_DIR}/tickets/queue/TICKET-test.md",
+    "content": "---\\nticket_id: null\\nsession_id: test\\nsequence: null\\nstatus: open\\ncycle_type: development\\nworktree_path: null\\n---\\n"
   },
   "transcript_path": "/dev/null",
   "cwd": ""
@@ -75,7 +96,7 @@ test_json=$(cat <<EOF
 EOF
 )
 
-if echo "$test_json" | bash "$HOOK" 2>&1; then
+if echo "$test_json" | run_engine 2>&1; then
     echo "  PASSED: Allowed workflow metadata with dirname fallback"
 else
     echo "  FAILED: Should have allowed workflow metadata with dirname fallback"